@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/collector"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+const DiffShortDescription = "Compare two inventory files"
+const DiffLongDescription = `Diff compares two files written by the collector, flat or aggregated,
+	e.g. of two clusters or two days, and prints the images only in A, only in B,
+	and the images whose metadata differs.
+	`
+
+func newDiffCommand() *cobra.Command {
+	var compareBy, format string
+
+	c := &cobra.Command{
+		Use:   "diff <a> <b>",
+		Short: DiffShortDescription,
+		Long:  DiffLongDescription,
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			diff(args[0], args[1], compareBy, format, cmd.OutOrStdout())
+		},
+	}
+	c.Flags().StringVar(&compareBy, "by", collector.CompareByDigest, "Compare images by [digest, tag], a changed digest of a tag is a changed image when comparing by tag, images without a digest are compared by tag")
+	c.Flags().StringVarP(&format, "output", "o", "text", "Report format [text, json, markdown]")
+
+	return c
+}
+
+// diff reads both inventories and writes the diff report
+func diff(a, b, compareBy, format string, w io.Writer) {
+	imageKey, err := collector.ImageKeyFor(compareBy)
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Invalid comparison")
+	}
+
+	imagesA := readInventory(a)
+	imagesB := readInventory(b)
+
+	report, err := collector.NewDiffReport(a, imagesA, b, imagesB, imageKey)
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not compare images")
+	}
+
+	if err = collector.WriteDiffReport(w, report, format); err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not write report")
+	}
+}
+
+func readInventory(filename string) *[]collector.CollectorImage {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not read " + filename)
+	}
+
+	images, err := collector.ReadInventory(data)
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not parse " + filename)
+	}
+	return images
+}
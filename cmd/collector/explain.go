@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/collector"
+	"github.com/SDA-SE/image-metadata-collector/internal/config"
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+const ExplainShortDescription = "Explain the collected values of the images of a namespace or pod"
+const ExplainLongDescription = `Explain runs the collector for a single namespace or pod and prints
+	the effective value of every field and where it came from,
+	the label/annotation keys looked up,
+	malformed values which fell back to defaults,
+	and the skip decision.
+	`
+
+func newExplainCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain <namespace> [pod]",
+		Short: ExplainShortDescription,
+		Long:  ExplainLongDescription,
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			podName := ""
+			if len(args) == 2 {
+				podName = args[1]
+			}
+			explain(cfg, args[0], podName, cmd.OutOrStdout())
+		},
+	}
+}
+
+// explain collects and converts the images of the namespace, optionally of a single pod, and explains them
+func explain(cfg *config.Config, namespaceName, podName string, w io.Writer) {
+	k8client := kubeclient.NewClient(&cfg.KubeConfig)
+
+	runConfig := cfg.RunConfig
+	runConfig.Provenance = true
+	initializeRunConfig(&runConfig, k8client)
+
+	namespace, err := k8client.GetNamespace(namespaceName)
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not retrieve namespace " + namespaceName)
+	}
+
+	k8Images, err := k8client.GetImages(&[]kubeclient.Namespace{*namespace})
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not retrieve images from K8")
+	}
+
+	if podName != "" {
+		var podImages []kubeclient.Image
+		for _, k8Image := range *k8Images {
+			if k8Image.PodName == podName {
+				podImages = append(podImages, k8Image)
+			}
+		}
+		k8Images = &podImages
+	}
+
+	images, err := collector.ConvertImages(k8Images, &cfg.CollectorImage, &cfg.AnnotationNames, &runConfig)
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not collect images")
+	}
+
+	if err = collector.Explain(w, images); err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not explain images")
+	}
+}
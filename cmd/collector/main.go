@@ -1,9 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/SDA-SE/image-metadata-collector/internal/collector"
 	"github.com/SDA-SE/image-metadata-collector/internal/config"
@@ -19,6 +23,9 @@ import (
 
 const AppName = "collector"
 
+// Version of the collector, set at build time with -ldflags "-X main.Version=<version>"
+var Version = "dev"
+
 const ShortDescription = "Collect images"
 const LongDescription = `Image Metadata Collector is a tool that will scan
 	'Namespace's,
@@ -40,9 +47,10 @@ func newCommand() *cobra.Command {
 	cfg := &config.Config{}
 
 	c := &cobra.Command{
-		Use:   AppName,
-		Short: ShortDescription,
-		Long:  LongDescription,
+		Use:     AppName,
+		Short:   ShortDescription,
+		Long:    LongDescription,
+		Version: Version,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			return initializeConfig(cmd)
 		},
@@ -53,15 +61,44 @@ func newCommand() *cobra.Command {
 
 	// Run Configuration
 	c.PersistentFlags().BoolVar(&cfg.Debug, "debug", false, "Set logging level to debug, default logging level is info")
-	c.Flags().StringSliceVarP(&cfg.RunConfig.ImageFilter, "image-filter", "s", []string{}, "Images to set the skip flag to true. Images as regex comma seperated without spaces. e.g. 'mock-service,mongo,openpolicyagent/opa,/istio/")
+	c.PersistentFlags().StringSliceVarP(&cfg.RunConfig.ImageFilter, "image-filter", "s", []string{}, "Images to set the skip flag to true. Images as regex comma seperated without spaces. e.g. 'mock-service,mongo,openpolicyagent/opa,/istio/")
+	c.PersistentFlags().StringVar(&cfg.RunConfig.ImageFilterSyntax, "image-filter-syntax", collector.FilterSyntaxRegex, "Syntax of the image filters [regex, glob], globs match the whole image, '*' includes '/'")
+	c.PersistentFlags().BoolVar(&cfg.RunConfig.ImageFilterAnchored, "image-filter-anchored", false, "Image filter regexes have to match the whole image instead of a part of it")
+	c.PersistentFlags().StringVar(&cfg.RunConfig.NamespaceMappingFile, "namespace-mapping-file", "", "Path to the namespace to team mapping JSON document")
+	c.PersistentFlags().StringVar(&cfg.RunConfig.NamespaceMappingConfigMap, "namespace-mapping-configmap", "", "Configmap containing the namespace to team mapping JSON document as '<namespace>/<name>', requires 'get' on configmaps")
+	c.PersistentFlags().StringVar(&cfg.RunConfig.NamespaceMappingConfigMapKey, "namespace-mapping-configmap-key", "collector.namespacemapping", "Key of the namespace to team mapping in the configmap")
+	c.PersistentFlags().StringSliceVar(&cfg.RunConfig.Precedence, "precedence", collector.DefaultPrecedence, "Sources of labels and annotations from the most to the least specific, sources not listed are ignored. The team mapping and the defaults are used if no source sets a value")
+	c.PersistentFlags().BoolVar(&cfg.RunConfig.Provenance, "provenance", false, "Add the source (annotation, label, team mapping, filter or default) and key of every field to the output")
+	c.PersistentFlags().BoolVar(&cfg.RunConfig.Aggregate, "aggregate", false, "Merge images with the same namespace, image, image id and settings into one entry with a list of locations and a replica count")
 	// Kubernetes Config
 	c.PersistentFlags().StringVar(&cfg.KubeConfig.ConfigFile, "kube-config", "", "absolute path to the kubeconfig file")
 	c.PersistentFlags().StringVar(&cfg.KubeConfig.Context, "kube-context", "", "The context to use to talk to the Kubernetes apiserver. If unset defaults to whatever your current-context is (kubectl config current-context)")
 	c.PersistentFlags().StringVar(&cfg.KubeConfig.MasterUrl, "master-url", "", "URL of the API server")
+	c.PersistentFlags().StringSliceVar(&cfg.KubeConfig.Input, "input", nil, "Files or directories of 'kubectl get -o json' dumps, YAML manifests or List objects to collect offline instead of from the API server, '-' reads stdin")
+	c.Flags().StringSliceVar(&cfg.KubeConfig.Contexts, "contexts", []string{}, "Kubeconfig contexts to collect in one run as '<context>=<environment>' or '<context>' using the context as environment")
+	c.Flags().StringVar(&cfg.KubeConfig.ConfigDir, "kube-config-dir", "", "Directory of kubeconfig files to collect in one run, using their current context and the file name without extension as environment")
+	c.Flags().StringVar(&cfg.RunConfig.ClusterOutput, "cluster-output", ClusterOutputEnvironment, "Output of several clusters [environment, combined], one file per environment or one combined file with a cluster per image")
+	c.PersistentFlags().StringVar(&cfg.KubeConfig.NamespaceSelector, "namespace-selector", "", "Label selector of the namespaces to collect, e.g. 'team=nazgul,env!=dev'")
+	c.PersistentFlags().StringVar(&cfg.KubeConfig.PodSelector, "pod-selector", "", "Label selector of the pods to collect")
+	c.PersistentFlags().StringSliceVar(&cfg.KubeConfig.Namespaces, "namespaces", []string{}, "Namespaces to collect, fetched one by one instead of listing all namespaces. Works with a Role per namespace, forbidden namespace metadata is skipped and forbidden pods are reported as errors")
+	c.PersistentFlags().StringSliceVar(&cfg.KubeConfig.ExcludeNamespaces, "exclude-namespaces", []string{}, "Namespaces to never collect")
+	c.PersistentFlags().IntVar(&cfg.KubeConfig.Concurrency, "concurrency", 10, "Number of namespaces collected in parallel")
+	c.PersistentFlags().Float32Var(&cfg.KubeConfig.QPS, "kube-qps", 50, "Maximum queries per second to the API server, 0 uses the client-go default")
+	c.PersistentFlags().IntVar(&cfg.KubeConfig.Burst, "kube-burst", 100, "Maximum burst of queries to the API server, 0 uses the client-go default")
+	c.PersistentFlags().BoolVar(&cfg.KubeConfig.PreDeploy, "pre-deploy", false, "Also collect the images declared in the pod templates of Deployments, StatefulSets, DaemonSets, Jobs and CronJobs which are not running yet, marking every image as running or declared")
+	c.PersistentFlags().Int64Var(&cfg.KubeConfig.PageSize, "page-size", 500, "Maximum number of namespaces or pods per list request, 0 lists all at once")
 
 	// Output/Storage Config
 	c.PersistentFlags().StringVar(&cfg.StorageConfig.StorageFlag, "storage", "api", "Write output to storage location [api, s3, git, local fs]")
-	c.PersistentFlags().StringVar(&cfg.StorageConfig.FileName, "filename", "", "Output filename, defaults to '<environment>-output.json'")
+	c.Flags().BoolVar(&cfg.RunConfig.Delta, "delta", false, "Store only the images added, removed or changed since the previous run, keyed by namespace, image and digest, requires --state-storage, not supported by the api storage and with --aggregate")
+	c.Flags().BoolVar(&cfg.RunConfig.ServiceDescription, "service-description", false, "Also store the team, description, product and contacts of every namespace, not supported by the api storage")
+	c.PersistentFlags().StringVar(&cfg.StorageConfig.ServiceDescriptionFileName, "service-description-filename", "", "Service description filename, defaults to '<environment>-service-description.json'")
+	c.Flags().StringVar(&cfg.StorageConfig.StateStorageFlag, "state-storage", "", "Storage location of the images of the previous run for --delta [s3, git, fs], using the same settings as the output storage")
+	c.Flags().StringVar(&cfg.StorageConfig.StateFileName, "state-filename", "", "State filename, defaults to '<environment>-state.json'")
+	c.PersistentFlags().StringVar(&cfg.StorageConfig.FileName, "filename", "", "Output filename, defaults to '<environment>-output.<extension of the output format>'")
+	c.PersistentFlags().StringVar(&cfg.RunConfig.OutputFormat, "output-format", collector.OutputFormatJSON, "Output format of the images ["+strings.Join(collector.OutputFormats(), ", ")+"], the api storage only accepts json")
+	c.PersistentFlags().BoolVar(&cfg.RunConfig.Envelope, "envelope", false, "Wrap the images in an object with the schema version, collector version, environment, time and Kubernetes server version, only json and yaml output, see 'collector schema'")
+	c.PersistentFlags().StringSliceVar(&cfg.RunConfig.CSVColumns, "csv-columns", collector.DefaultCSVColumns, "Columns of the csv output format, the json field names of the images")
 	c.PersistentFlags().StringVar(&cfg.StorageConfig.S3BucketName, "s3-bucket", "", "S3 Bucket to store image collector results")
 	c.PersistentFlags().StringVar(&cfg.StorageConfig.S3Endpoint, "s3-endpoint", "", "S3 Endpoint (e.g. minio)")
 	c.PersistentFlags().StringVar(&cfg.StorageConfig.S3Region, "s3-region", "", "S3 region")
@@ -112,6 +149,12 @@ func newCommand() *cobra.Command {
 	}
 
 	c.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+
+	c.AddCommand(newExplainCommand(cfg))
+	c.AddCommand(newValidateCommand(cfg))
+	c.AddCommand(newWatchCommand(cfg))
+	c.AddCommand(newDiffCommand())
+	c.AddCommand(newSchemaCommand(cfg))
 	return c
 }
 
@@ -146,35 +189,455 @@ func bindFlags(cmd *cobra.Command, v *viper.Viper) {
 	})
 }
 
+// Outputs of a run with several clusters
+const (
+	ClusterOutputCombined    = "combined"
+	ClusterOutputEnvironment = "environment"
+)
+
 // run starts the collector and metrics endpoint
 func run(cfg *config.Config) {
+	initializeOutputFormat(cfg)
+
+	clusters, err := cfg.KubeConfig.Clusters()
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Invalid clusters")
+	}
+	if len(clusters) > 0 {
+		runClusters(cfg, clusters)
+		return
+	}
+
 	k8client := kubeclient.NewClient(&cfg.KubeConfig)
+	output := newOutput(cfg, cfg.Environment)
 
-	storage, err := storage.NewStorage(&cfg.StorageConfig, cfg.Environment)
+	runConfig := &cfg.RunConfig
+	initializeRunConfig(runConfig, k8client)
 
+	sink, err := output.newSink(runConfig)
 	if err != nil {
-		log.Fatal().Stack().Err(err).Msg("Could not create storage for: " + cfg.StorageConfig.StorageFlag)
+		log.Fatal().Stack().Err(err).Msg("Invalid output format")
 	}
+	namespaces := collectImages(k8client, &cfg.CollectorImage, &cfg.AnnotationNames, runConfig, sink.add)
 
-	collectorDefaults := &cfg.CollectorImage
-	annotationNames := &cfg.AnnotationNames
-	runConfig := &cfg.RunConfig
+	envelope := newEnvelope(cfg, cfg.Environment, k8client)
+	if err = sink.store(envelope); err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not store collected images")
+	}
+
+	if runConfig.ServiceDescription {
+		descriptions := collector.ConvertServiceDescriptions(namespaces, &cfg.CollectorImage, &cfg.AnnotationNames, runConfig)
+		if err = output.storeServiceDescriptions(descriptions); err != nil {
+			log.Fatal().Stack().Err(err).Msg("Could not store service descriptions")
+		}
+	}
+}
+
+// runClusters collects the images of all clusters into one combined output or one output per environment
+func runClusters(cfg *config.Config, clusters []kubeclient.Cluster) {
+	var combinedOutput *output
+	var combinedSink imageSink
+	switch cfg.RunConfig.ClusterOutput {
+	case ClusterOutputCombined:
+		combinedOutput = newOutput(cfg, cfg.Environment)
+		var err error
+		if combinedSink, err = combinedOutput.newSink(&cfg.RunConfig); err != nil {
+			log.Fatal().Stack().Err(err).Msg("Invalid output format")
+		}
+	case ClusterOutputEnvironment:
+		if cfg.StorageConfig.FileName != "" || cfg.StorageConfig.StateFileName != "" || cfg.StorageConfig.ServiceDescriptionFileName != "" {
+			log.Fatal().Msg("Filenames can't be set with one output per environment, the files are named by environment")
+		}
+	default:
+		log.Fatal().Msg("Cluster output " + cfg.RunConfig.ClusterOutput + " is not supported")
+	}
+
+	var combinedDescriptions []collector.ServiceDescription
+	for _, cluster := range clusters {
+		log.Info().Str("cluster", cluster.Name).Str("environment", cluster.Environment).Msg("Collecting cluster")
+
+		k8client := kubeclient.NewClient(&cluster.KubeConfig)
+
+		// Copied, as the namespace mapping may be loaded from each cluster
+		runConfig := cfg.RunConfig
+		initializeRunConfig(&runConfig, k8client)
+
+		var clusterOutput *output
+		sink := combinedSink
+		if combinedOutput == nil {
+			clusterOutput = newOutput(cfg, cluster.Environment)
+			var err error
+			if sink, err = clusterOutput.newSink(&runConfig); err != nil {
+				log.Fatal().Stack().Err(err).Msg("Invalid output format")
+			}
+		}
+
+		defaults := cfg.CollectorImage
+		defaults.Environment = cluster.Environment
+
+		namespaces := collectImages(k8client, &defaults, &cfg.AnnotationNames, &runConfig, func(image *collector.CollectorImage) error {
+			image.Cluster = cluster.Name
+			return sink.add(image)
+		})
+
+		descriptions := &[]collector.ServiceDescription{}
+		if runConfig.ServiceDescription {
+			descriptions = collector.ConvertServiceDescriptions(namespaces, &defaults, &cfg.AnnotationNames, &runConfig)
+			for i := range *descriptions {
+				(*descriptions)[i].Cluster = cluster.Name
+			}
+		}
+
+		if combinedOutput != nil {
+			combinedDescriptions = append(combinedDescriptions, *descriptions...)
+			continue
+		}
+		envelope := newEnvelope(cfg, cluster.Environment, k8client)
+		if envelope != nil {
+			envelope.Cluster = cluster.Name
+		}
+		if err := sink.store(envelope); err != nil {
+			log.Fatal().Stack().Err(err).Str("cluster", cluster.Name).Msg("Could not store collected images")
+		}
+		if err := clusterOutput.storeServiceDescriptions(descriptions); err != nil {
+			log.Fatal().Stack().Err(err).Str("cluster", cluster.Name).Msg("Could not store service descriptions")
+		}
+	}
+
+	if combinedOutput != nil {
+		// The clusters may run different server versions, which are left out
+		envelope := newEnvelope(cfg, cfg.Environment, nil)
+		if err := combinedSink.store(envelope); err != nil {
+			log.Fatal().Stack().Err(err).Msg("Could not store collected images")
+		}
+		if err := combinedOutput.storeServiceDescriptions(&combinedDescriptions); err != nil {
+			log.Fatal().Stack().Err(err).Msg("Could not store service descriptions")
+		}
+	}
+}
 
-	// Collect images from K8
-	k8Images, err := k8client.GetAllImagesForAllNamespaces()
+// collectImages collects the namespaces and the images from K8 page by page, converting & cleaning them to collector images
+// which are passed to fn one by one
+func collectImages(k8client *kubeclient.Client, defaults *collector.CollectorImage, annotationNames *collector.AnnotationNames, runConfig *collector.RunConfig, fn func(image *collector.CollectorImage) error) *[]kubeclient.Namespace {
+	namespaces, err := k8client.GetNamespaces()
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not retrieve namespaces from K8")
+	}
+
+	err = k8client.ForEachImage(namespaces, func(k8Image kubeclient.Image) error {
+		image, err := collector.ConvertImage(k8Image, defaults, annotationNames, runConfig)
+		if err != nil {
+			return err
+		}
+		return fn(image)
+	})
 	if err != nil {
 		log.Fatal().Stack().Err(err).Msg("Could not retrieve images from K8")
 	}
 
-	// Convert & Clean k8 images to collector images
-	images, err := collector.ConvertImages(k8Images, collectorDefaults, annotationNames, runConfig)
+	return namespaces
+}
+
+// imageSink receives the images while they are collected and stores them afterwards
+type imageSink interface {
+	add(image *collector.CollectorImage) error
+	// store writes the images, wrapped in the envelope if it is not nil
+	store(envelope *collector.EnvelopeMetadata) error
+}
+
+// newSink returns the sink keeping as little as the output allows in memory: aggregated images are merged and
+// formats encoding image by image are encoded while collecting. Delta output and lists encoded as a whole,
+// e.g. json, need all images.
+func (o *output) newSink(runConfig *collector.RunConfig) (imageSink, error) {
+	if runConfig.Delta {
+		return &listSink{output: o, runConfig: runConfig}, nil
+	}
+	if runConfig.Aggregate {
+		return &aggregateSink{aggregator: collector.NewAggregator(), storage: o.storage, runConfig: runConfig}, nil
+	}
+
+	encoder, err := collector.NewEncoder(runConfig.OutputFormat, &collector.EncoderOptions{CSVColumns: runConfig.CSVColumns})
 	if err != nil {
-		log.Fatal().Stack().Err(err).Msg("Could not collect images")
+		return nil, err
+	}
+	if encoder.NewItemEncoder == nil || runConfig.Envelope {
+		return &listSink{output: o, runConfig: runConfig}, nil
 	}
 
-	// Store images
-	err = collector.Store(images, storage, collector.JsonIndentMarshal)
+	sink := &streamSink{storage: o.storage}
+	if sink.encoder, err = encoder.NewItemEncoder(&sink.data); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// listSink keeps all images
+type listSink struct {
+	images    []collector.CollectorImage
+	output    *output
+	runConfig *collector.RunConfig
+}
+
+func (s *listSink) add(image *collector.CollectorImage) error {
+	s.images = append(s.images, *image)
+	return nil
+}
+
+func (s *listSink) store(envelope *collector.EnvelopeMetadata) error {
+	return s.output.store(&s.images, s.runConfig, envelope)
+}
+
+// aggregateSink keeps the aggregated images only
+type aggregateSink struct {
+	aggregator *collector.Aggregator
+	storage    io.Writer
+	runConfig  *collector.RunConfig
+}
+
+func (s *aggregateSink) add(image *collector.CollectorImage) error {
+	return s.aggregator.Add(image)
+}
+
+func (s *aggregateSink) store(envelope *collector.EnvelopeMetadata) error {
+	marshal, err := newMarshal(s.runConfig, envelope)
 	if err != nil {
-		log.Fatal().Stack().Err(err).Msg("Could not store collected images")
+		return err
+	}
+	return collector.Store(s.aggregator.Images(), s.storage, marshal)
+}
+
+// streamSink encodes the images while they are collected
+type streamSink struct {
+	// data is the encoded output, written at once as the storages upload or commit whole files
+	data    bytes.Buffer
+	encoder collector.ItemEncoder
+	storage io.Writer
+}
+
+func (s *streamSink) add(image *collector.CollectorImage) error {
+	return s.encoder.Encode(image)
+}
+
+func (s *streamSink) store(envelope *collector.EnvelopeMetadata) error {
+	if err := s.encoder.Close(); err != nil {
+		return err
+	}
+	_, err := s.storage.Write(s.data.Bytes())
+	return err
+}
+
+// output is the storage of the images of an environment and, for delta output, of their previous state
+type output struct {
+	storage      io.Writer
+	stateStorage storage.StateStorage
+	// serviceDescriptionStorage is nil if no service descriptions are stored
+	serviceDescriptionStorage io.Writer
+}
+
+// newOutput creates the storages before collecting, so misconfigurations fail fast
+func newOutput(cfg *config.Config, environment string) *output {
+	o := &output{}
+
+	var err error
+	if cfg.RunConfig.Delta {
+		o.stateStorage, err = storage.NewStateStorage(&cfg.StorageConfig, environment)
+		if err != nil {
+			log.Fatal().Stack().Err(err).Msg("Could not create state storage for: " + cfg.StorageConfig.StateStorageFlag)
+		}
+	}
+
+	o.storage, err = storage.NewStorage(&cfg.StorageConfig, environment)
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not create storage for: " + cfg.StorageConfig.StorageFlag)
+	}
+
+	if cfg.RunConfig.ServiceDescription {
+		o.serviceDescriptionStorage, err = storage.NewServiceDescriptionStorage(&cfg.StorageConfig, environment)
+		if err != nil {
+			log.Fatal().Stack().Err(err).Msg("Could not create service description storage for: " + cfg.StorageConfig.StorageFlag)
+		}
+	}
+
+	return o
+}
+
+// store writes the images or their delta, wrapped in the envelope if it is not nil
+func (o *output) store(images *[]collector.CollectorImage, runConfig *collector.RunConfig, envelope *collector.EnvelopeMetadata) error {
+	if runConfig.Delta {
+		return storeDelta(images, o.stateStorage, o.storage)
+	}
+	return storeImages(images, runConfig, envelope, o.storage)
+}
+
+// storeServiceDescriptions writes the service descriptions as json, if they are stored
+func (o *output) storeServiceDescriptions(descriptions *[]collector.ServiceDescription) error {
+	if o.serviceDescriptionStorage == nil {
+		return nil
+	}
+
+	data, err := collector.JsonIndentMarshal(descriptions)
+	if err != nil {
+		return err
+	}
+	_, err = o.serviceDescriptionStorage.Write(data)
+	return err
+}
+
+// storeDelta writes the images added, removed or changed since the images in the state storage to the storage
+// and replaces the state with the images
+func storeDelta(images *[]collector.CollectorImage, stateStorage storage.StateStorage, storage io.Writer) error {
+	data, err := stateStorage.Read()
+	if err != nil {
+		return err
+	}
+
+	previousImages := &[]collector.CollectorImage{}
+	if data != nil {
+		if previousImages, err = collector.ReadInventory(data); err != nil {
+			return fmt.Errorf("could not parse the previous state: %w", err)
+		}
+	} else {
+		log.Info().Msg("No previous state found, reporting all images as added")
+	}
+
+	delta, err := collector.DiffImages(previousImages, images, collector.KeyByImageAndDigest)
+	if err != nil {
+		return err
+	}
+	log.Info().Int("added", len(delta.Added)).Int("removed", len(delta.Removed)).Int("changed", len(delta.Changed)).Msg("Images changed since the previous run")
+
+	data, err = collector.JsonIndentMarshal(delta)
+	if err != nil {
+		return err
+	}
+	if _, err = storage.Write(data); err != nil {
+		return err
+	}
+
+	return collector.Store(images, stateStorage, collector.JsonIndentMarshal)
+}
+
+// storeImages writes the images in the output format to the storage, optionally merged by namespace, image, image id and settings
+// and wrapped in the envelope if it is not nil
+func storeImages(images *[]collector.CollectorImage, runConfig *collector.RunConfig, envelope *collector.EnvelopeMetadata, storage io.Writer) error {
+	marshal, err := newMarshal(runConfig, envelope)
+	if err != nil {
+		return err
+	}
+
+	if runConfig.Aggregate {
+		aggregatedImages, err := collector.AggregateImages(images)
+		if err != nil {
+			return err
+		}
+		return collector.Store(aggregatedImages, storage, marshal)
+	}
+	return collector.Store(images, storage, marshal)
+}
+
+// newMarshal returns the marshal of the output format, wrapping the images in the envelope if it is not nil
+func newMarshal(runConfig *collector.RunConfig, envelope *collector.EnvelopeMetadata) (collector.JsonMarshal, error) {
+	encoder, err := collector.NewEncoder(runConfig.OutputFormat, &collector.EncoderOptions{CSVColumns: runConfig.CSVColumns})
+	if err != nil {
+		return nil, err
+	}
+	if envelope != nil {
+		return collector.EnvelopeMarshal(envelope, encoder.Marshal), nil
+	}
+	return encoder.Marshal, nil
+}
+
+// initializeOutputFormat validates the output format and names the output file after it
+func initializeOutputFormat(cfg *config.Config) {
+	encoder, err := collector.NewEncoder(cfg.RunConfig.OutputFormat, &collector.EncoderOptions{CSVColumns: cfg.RunConfig.CSVColumns})
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Invalid output format")
 	}
+	if cfg.RunConfig.Delta && cfg.RunConfig.OutputFormat != collector.OutputFormatJSON {
+		log.Fatal().Msg("Delta output is only supported in the json output format")
+	}
+	if cfg.StorageConfig.StorageFlag == "api" && cfg.RunConfig.OutputFormat != collector.OutputFormatJSON {
+		log.Fatal().Msg("The api storage only accepts the json output format")
+	}
+	if cfg.RunConfig.Delta && cfg.StorageConfig.StorageFlag == "api" {
+		log.Fatal().Msg("Delta output can't be written to the api storage, which expects a list of images")
+	}
+	if cfg.RunConfig.Delta && cfg.RunConfig.Aggregate {
+		log.Fatal().Msg("Delta output is not supported with aggregated images")
+	}
+	if cfg.RunConfig.Envelope && cfg.RunConfig.Delta {
+		log.Fatal().Msg("The envelope is not supported with delta output")
+	}
+	if cfg.RunConfig.Envelope && !encoder.Envelope {
+		log.Fatal().Msg("The envelope is not supported in the " + cfg.RunConfig.OutputFormat + " output format")
+	}
+	cfg.StorageConfig.FileExtension = encoder.Extension
+}
+
+// newEnvelope returns the metadata of the images collected by the client, nil if the images are not wrapped in an envelope.
+// Without client, e.g. for several clusters, the metadata has no server version.
+func newEnvelope(cfg *config.Config, environment string, k8client *kubeclient.Client) *collector.EnvelopeMetadata {
+	if !cfg.RunConfig.Envelope {
+		return nil
+	}
+
+	envelope := &collector.EnvelopeMetadata{
+		SchemaVersion:    collector.SchemaVersion,
+		CollectorVersion: Version,
+		Environment:      environment,
+		GeneratedAt:      time.Now().UTC(),
+	}
+	if k8client != nil {
+		serverVersion, err := k8client.ServerVersion()
+		if err != nil {
+			log.Warn().Err(err).Msg("Could not retrieve the server version, it is left out of the envelope")
+		}
+		envelope.KubeServerVersion = serverVersion
+	}
+	return envelope
+}
+
+// initializeRunConfig validates the run configuration and loads the namespace mapping
+func initializeRunConfig(runConfig *collector.RunConfig, k8client *kubeclient.Client) {
+	err := collector.ValidatePrecedence(runConfig.Precedence)
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Invalid precedence")
+	}
+
+	if err = runConfig.CompileImageFilters(); err != nil {
+		log.Fatal().Stack().Err(err).Msg("Invalid image filter")
+	}
+
+	runConfig.NamespaceToTeam, err = loadNamespaceMapping(runConfig, k8client)
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not load namespace mapping")
+	}
+}
+
+// loadNamespaceMapping reads the namespace to team mapping from a file or a configmap, if configured
+func loadNamespaceMapping(runConfig *collector.RunConfig, k8client *kubeclient.Client) (*collector.NamespaceMapping, error) {
+	var data []byte
+
+	switch {
+	case runConfig.NamespaceMappingFile != "":
+		content, err := os.ReadFile(runConfig.NamespaceMappingFile)
+		if err != nil {
+			return nil, err
+		}
+		data = content
+	case runConfig.NamespaceMappingConfigMap != "":
+		namespace, name, found := strings.Cut(runConfig.NamespaceMappingConfigMap, "/")
+		if !found {
+			return nil, fmt.Errorf("namespace mapping configmap %s is not in the format '<namespace>/<name>'", runConfig.NamespaceMappingConfigMap)
+		}
+		content, err := k8client.GetConfigMapData(namespace, name, runConfig.NamespaceMappingConfigMapKey)
+		if err != nil {
+			return nil, err
+		}
+		data = []byte(content)
+	default:
+		return nil, nil
+	}
+
+	return collector.ParseNamespaceMapping(data)
 }
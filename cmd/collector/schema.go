@@ -0,0 +1,41 @@
+package main
+
+import (
+	"io"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/collector"
+	"github.com/SDA-SE/image-metadata-collector/internal/config"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+const SchemaShortDescription = "Print the JSON Schema of the json output"
+const SchemaLongDescription = `Schema prints the JSON Schema of the images stored in the json output format,
+	generated from the image fields, e.g. to validate uploads.
+	With --aggregate the schema of the aggregated images is printed,
+	with --envelope the schema of the envelope of the current schema version.
+	`
+
+func newSchemaCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: SchemaShortDescription,
+		Long:  SchemaLongDescription,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			printSchema(cfg, cmd.OutOrStdout())
+		},
+	}
+}
+
+// printSchema writes the JSON Schema of the output configured by the run configuration
+func printSchema(cfg *config.Config, w io.Writer) {
+	data, err := collector.JsonIndentMarshal(collector.JSONSchema(cfg.RunConfig.Aggregate, cfg.RunConfig.Envelope))
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not marshal schema")
+	}
+	if _, err = w.Write(append(data, '\n')); err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not write schema")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/collector"
+	"github.com/SDA-SE/image-metadata-collector/internal/config"
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+const ValidateShortDescription = "Report malformed and unknown labels and annotations"
+const ValidateLongDescription = `Validate walks all namespaces and pods and reports
+	keys below the configured annotation names which are unknown, e.g. typos,
+	values which can not be parsed as bool or int,
+	and invalid namespace filter regexes.
+	Exits with code 1 if anything is reported.
+	`
+
+func newValidateCommand(cfg *config.Config) *cobra.Command {
+	var format string
+
+	c := &cobra.Command{
+		Use:   "validate",
+		Short: ValidateShortDescription,
+		Long:  ValidateLongDescription,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if findings := validate(cfg, format, cmd.OutOrStdout()); findings > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	c.Flags().StringVarP(&format, "output", "o", "text", "Report format [text, json]")
+
+	return c
+}
+
+// validate lints the labels and annotations of all namespaces, pods and workloads and returns the number of findings
+func validate(cfg *config.Config, format string, w io.Writer) int {
+	k8client := kubeclient.NewClient(&cfg.KubeConfig)
+
+	namespaces, err := k8client.GetNamespaces()
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not retrieve namespaces from K8")
+	}
+
+	k8Images, err := k8client.GetImages(namespaces)
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not retrieve images from K8")
+	}
+
+	findings := collector.Lint(namespaces, k8Images, &cfg.AnnotationNames)
+	if err = collector.WriteLintReport(w, findings, format); err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not write report")
+	}
+
+	return len(findings)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/collector"
+	"github.com/SDA-SE/image-metadata-collector/internal/config"
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/storage"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+const WatchShortDescription = "Watch namespaces and pods and store the images on change"
+const WatchLongDescription = `Watch keeps the namespaces and pods in memory using shared informers
+	and stores the images whenever they changed, debounced to collect bursts of changes,
+	and on every periodic resync.
+	Requires 'list' and 'watch' on pods and namespaces.
+	`
+
+type watchConfig struct {
+	Debounce time.Duration
+	Resync   time.Duration
+}
+
+func newWatchCommand(cfg *config.Config) *cobra.Command {
+	watchCfg := &watchConfig{}
+
+	c := &cobra.Command{
+		Use:   "watch",
+		Short: WatchShortDescription,
+		Long:  WatchLongDescription,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			watch(ctx, cfg, watchCfg)
+		},
+	}
+	c.Flags().DurationVar(&watchCfg.Debounce, "debounce", 5*time.Second, "Time to wait for further changes before storing the images")
+	c.Flags().DurationVar(&watchCfg.Resync, "resync", 10*time.Minute, "Interval of the informer resync, the images are stored on every resync")
+
+	return c
+}
+
+// watch stores the images on every debounced change and periodic resync until the context is done
+func watch(ctx context.Context, cfg *config.Config, watchCfg *watchConfig) {
+	initializeOutputFormat(cfg)
+
+	k8client := kubeclient.NewClient(&cfg.KubeConfig)
+	runConfig := &cfg.RunConfig
+	initializeRunConfig(runConfig, k8client)
+
+	changes := make(chan struct{}, 1)
+	watcher, err := k8client.Watch(ctx, watchCfg.Resync, func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not watch namespaces and pods")
+	}
+	log.Info().Msg("Watching namespaces and pods")
+
+	envelope := newEnvelope(cfg, cfg.Environment, k8client)
+
+	// Created once, e.g. to clone a git repository only once, every write replaces the stored images
+	w, err := storage.NewStorage(&cfg.StorageConfig, cfg.Environment)
+	if err != nil {
+		log.Fatal().Stack().Err(err).Msg("Could not create storage for: " + cfg.StorageConfig.StorageFlag)
+	}
+
+	// The images are compared instead of the output, which may contain the time it was generated
+	var stored []byte
+	store := func(force bool) {
+		images, err := collectWatchedImages(watcher, cfg)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("Could not collect images")
+			return
+		}
+		key, err := json.Marshal(images)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("Could not compare images")
+			return
+		}
+		if !force && bytes.Equal(key, stored) {
+			log.Debug().Msg("Images did not change, not storing them")
+			return
+		}
+
+		if envelope != nil {
+			envelope.GeneratedAt = time.Now().UTC()
+		}
+		var data bytes.Buffer
+		if err = storeImages(images, &cfg.RunConfig, envelope, &data); err != nil {
+			log.Error().Stack().Err(err).Msg("Could not encode collected images")
+			return
+		}
+		if _, err = w.Write(data.Bytes()); err != nil {
+			log.Error().Stack().Err(err).Msg("Could not store collected images")
+			return
+		}
+		stored = key
+	}
+
+	store(true)
+
+	resync := time.NewTicker(watchCfg.Resync)
+	defer resync.Stop()
+	var debounce <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Stopped watching")
+			return
+		case <-changes:
+			if debounce == nil {
+				debounce = time.After(watchCfg.Debounce)
+			}
+		case <-debounce:
+			debounce = nil
+			store(false)
+		case <-resync.C:
+			watcher.ResetWorkloads()
+			store(true)
+		}
+	}
+}
+
+// collectWatchedImages converts the images from the informer caches like run
+func collectWatchedImages(watcher *kubeclient.Watcher, cfg *config.Config) (*[]collector.CollectorImage, error) {
+	k8Images, err := watcher.Images()
+	if err != nil {
+		return nil, err
+	}
+
+	return collector.ConvertImages(k8Images, &cfg.CollectorImage, &cfg.AnnotationNames, &cfg.RunConfig)
+}
@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"encoding/json"
+)
+
+// ImageLocation is a single occurrence of an aggregated image
+type ImageLocation struct {
+	Pod           string `json:"pod"`
+	ContainerName string `json:"container_name"`
+	ContainerRole string `json:"container_role"`
+	WorkloadKind  string `json:"workload_kind"`
+	WorkloadName  string `json:"workload_name"`
+	WorkloadUid   string `json:"workload_uid"`
+}
+
+// AggregatedImage merges all collector images sharing the same namespace, image, image id
+// and effective settings into one entry with the list of their locations
+type AggregatedImage struct {
+	CollectorImage
+
+	Replicas  int             `json:"replicas"`
+	Locations []ImageLocation `json:"locations"`
+}
+
+// splitLocation returns the location of the image and a copy of the image without it
+func splitLocation(ci CollectorImage) (CollectorImage, ImageLocation) {
+	location := ImageLocation{
+		Pod:           ci.Pod,
+		ContainerName: ci.ContainerName,
+		ContainerRole: ci.ContainerRole,
+		WorkloadKind:  ci.WorkloadKind,
+		WorkloadName:  ci.WorkloadName,
+		WorkloadUid:   ci.WorkloadUid,
+	}
+
+	ci.Pod = ""
+	ci.ContainerName = ""
+	ci.ContainerRole = ""
+	ci.WorkloadKind = ""
+	ci.WorkloadName = ""
+	ci.WorkloadUid = ""
+
+	return ci, location
+}
+
+// AggregateImages merges images by namespace, image, image id and effective settings.
+// The order of the first occurrence of each image is kept.
+func AggregateImages(images *[]CollectorImage) (*[]AggregatedImage, error) {
+	aggregator := NewAggregator()
+	for i := range *images {
+		if err := aggregator.Add(&(*images)[i]); err != nil {
+			return nil, err
+		}
+	}
+	return aggregator.Images(), nil
+}
+
+// Aggregator merges images one by one like AggregateImages, e.g. while they are collected,
+// so only the aggregated images are kept in memory
+type Aggregator struct {
+	aggregated []AggregatedImage
+	indexByKey map[string]int
+}
+
+func NewAggregator() *Aggregator {
+	return &Aggregator{aggregated: []AggregatedImage{}, indexByKey: map[string]int{}}
+}
+
+// Add merges the image into the aggregated image of its namespace, image, image id and settings
+func (a *Aggregator) Add(image *CollectorImage) error {
+	ci, location := splitLocation(*image)
+
+	// All remaining fields are settings, so the image without its location is the key
+	key, err := json.Marshal(ci)
+	if err != nil {
+		return err
+	}
+
+	idx, ok := a.indexByKey[string(key)]
+	if !ok {
+		idx = len(a.aggregated)
+		a.indexByKey[string(key)] = idx
+		a.aggregated = append(a.aggregated, AggregatedImage{CollectorImage: ci, Locations: []ImageLocation{}})
+	}
+
+	a.aggregated[idx].Locations = append(a.aggregated[idx].Locations, location)
+	a.aggregated[idx].Replicas = len(a.aggregated[idx].Locations)
+	return nil
+}
+
+// Images returns the aggregated images in the order of the first occurrence of each image
+func (a *Aggregator) Images() *[]AggregatedImage {
+	return &a.aggregated
+}
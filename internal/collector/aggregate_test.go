@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateImages(t *testing.T) {
+	replica := func(pod string, team string) CollectorImage {
+		return CollectorImage{
+			Namespace:     "myNamespace",
+			Image:         "quay.io/name:tag",
+			ImageId:       "quay.io/name@sha256:1234",
+			Pod:           pod,
+			ContainerName: "app",
+			ContainerRole: "app",
+			WorkloadKind:  "Deployment",
+			WorkloadName:  "my-deployment",
+			WorkloadUid:   "uid",
+			Team:          team,
+		}
+	}
+	location := func(pod string) ImageLocation {
+		return ImageLocation{
+			Pod:           pod,
+			ContainerName: "app",
+			ContainerRole: "app",
+			WorkloadKind:  "Deployment",
+			WorkloadName:  "my-deployment",
+			WorkloadUid:   "uid",
+		}
+	}
+	settings := func(team string) CollectorImage {
+		return CollectorImage{
+			Namespace: "myNamespace",
+			Image:     "quay.io/name:tag",
+			ImageId:   "quay.io/name@sha256:1234",
+			Team:      team,
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		images   []CollectorImage
+		expected []AggregatedImage
+	}{
+		{
+			name:     "EmptyInputResultsInEmptyOutput",
+			images:   []CollectorImage{},
+			expected: []AggregatedImage{},
+		},
+		{
+			name:   "ReplicasAreMerged",
+			images: []CollectorImage{replica("pod-1", "team"), replica("pod-2", "team"), replica("pod-3", "team")},
+			expected: []AggregatedImage{{
+				CollectorImage: settings("team"),
+				Replicas:       3,
+				Locations:      []ImageLocation{location("pod-1"), location("pod-2"), location("pod-3")},
+			}},
+		},
+		{
+			name:   "DifferentSettingsAreNotMerged",
+			images: []CollectorImage{replica("pod-1", "team-a"), replica("pod-2", "team-b"), replica("pod-3", "team-a")},
+			expected: []AggregatedImage{{
+				CollectorImage: settings("team-a"),
+				Replicas:       2,
+				Locations:      []ImageLocation{location("pod-1"), location("pod-3")},
+			}, {
+				CollectorImage: settings("team-b"),
+				Replicas:       1,
+				Locations:      []ImageLocation{location("pod-2")},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := AggregateImages(&tc.images)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, *result)
+		})
+	}
+}
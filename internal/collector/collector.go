@@ -3,8 +3,6 @@ package collector
 import (
 	"errors"
 	"io"
-	"maps"
-	"regexp"
 	"strings"
 
 	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
@@ -20,10 +18,31 @@ type AnnotationNames struct {
 }
 
 type CollectorImage struct {
+	// Name of the cluster, only set if several clusters are collected in one run
+	Cluster   string `json:"cluster,omitempty"`
 	Namespace string `json:"namespace"`
 	Image     string `json:"image"`
 	ImageId   string `json:"image_id"`
 
+	// Normalised image reference, the digest is taken from the image id for tag-only references
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	Digest     string `json:"digest"`
+
+	// Location of the image: pod, container and its role (app, init, sidecar, ephemeral) and
+	// the top-level workload owning the pod, e.g. Deployment, StatefulSet, DaemonSet or CronJob.
+	// Empty in aggregated output, where the locations are listed separately.
+	Pod           string `json:"pod,omitempty"`
+	ContainerName string `json:"container_name,omitempty"`
+	ContainerRole string `json:"container_role,omitempty"`
+	WorkloadKind  string `json:"workload_kind,omitempty"`
+	WorkloadName  string `json:"workload_name,omitempty"`
+	WorkloadUid   string `json:"workload_uid,omitempty"`
+
+	// State is running or declared in pre-deploy mode, declared images are only part of a workload's pod template yet
+	State string `json:"state,omitempty"`
+
 	// Fields from annotations and labels
 	Environment            string   `json:"environment"`
 	Product                string   `json:"product"`
@@ -52,55 +71,117 @@ type CollectorImage struct {
 	IsScanRunAsPrivileged            bool  `json:"is_scan_run_as_privileged"`
 	IsPotentiallyRunningAsPrivileged bool  `json:"is_scan_potentially_running_as_privileged"`
 	ScanLifetimeMaxDays              int64 `json:"scan_lifetime_max_days"`
+
+	// Source of every field by its json name, only part of the output if enabled in the RunConfig
+	Provenance map[string]Provenance `json:"provenance,omitempty"`
+
+	// Error is set for namespaces which could not be collected, e.g. because listing pods is forbidden.
+	// The entry has no image and is always skipped.
+	Error string `json:"error,omitempty"`
 }
 
 type RunConfig struct {
-	ImageFilter     []string
-	NamespaceToTeam []string
+	ImageFilter []string
+	// ImageFilterSyntax is regex or glob, ImageFilterAnchored requires regexes to match the whole image
+	ImageFilterSyntax   string
+	ImageFilterAnchored bool
+	Aggregate           bool
+	// Delta stores only the images added, removed or changed since the previous run, see DiffImages
+	Delta bool
+	// ServiceDescription additionally stores a description of every namespace, see ConvertServiceDescriptions
+	ServiceDescription bool
+	// ClusterOutput stores the images of several clusters combined or one file per environment
+	ClusterOutput string
+	// OutputFormat of the stored images, see NewEncoder. CSVColumns are the json field names written as csv columns.
+	OutputFormat string
+	CSVColumns   []string
+	// Envelope wraps the stored images with their metadata, see EnvelopeMetadata
+	Envelope bool
+
+	// Sources of labels and annotations from the most to the least specific, see DefaultPrecedence
+	Precedence []string
+	// Provenance adds the source of every field to the output
+	Provenance bool
+
+	// Team mapping applied between the cluster wide defaults and the pod annotations,
+	// loaded from NamespaceMappingFile or NamespaceMappingConfigMap (<namespace>/<name>)
+	NamespaceToTeam              *NamespaceMapping
+	NamespaceMappingFile         string
+	NamespaceMappingConfigMap    string
+	NamespaceMappingConfigMapKey string
+
+	imageFilters         []ImageFilter
+	imageFiltersCompiled bool
 }
 
-// convertK8ImageToCollectorImage by considering the images labels, annotations and cluster wide defaults
-func convertK8ImageToCollectorImage(k8Image kubeclient.Image, defaults *CollectorImage, annotationNames *AnnotationNames) *CollectorImage {
-	tags := k8Image.Labels
-	if tags == nil {
-		tags = k8Image.Annotations
-	} else {
-		maps.Copy(tags, k8Image.Annotations)
+// CompileImageFilters compiles the image filters once, an invalid filter is an error
+func (runConfig *RunConfig) CompileImageFilters() error {
+	filters, err := CompileImageFilters(runConfig.ImageFilter, runConfig.ImageFilterSyntax, runConfig.ImageFilterAnchored)
+	if err != nil {
+		return err
+	}
+	runConfig.imageFilters = filters
+	runConfig.imageFiltersCompiled = true
+	return nil
+}
+
+// compileImageFiltersOnce compiles the image filters unless CompileImageFilters already did,
+// so images are never converted without their filters
+func (runConfig *RunConfig) compileImageFiltersOnce() error {
+	if runConfig.imageFiltersCompiled {
+		return nil
 	}
+	return runConfig.CompileImageFilters()
+}
+
+// convertK8ImageToCollectorImage by considering the images labels, annotations and cluster wide defaults
+// in the order of the precedence
+func convertK8ImageToCollectorImage(k8Image kubeclient.Image, defaults *CollectorImage, annotationNames *AnnotationNames, precedence []string) *CollectorImage {
+	tags, sources := mergeTags(&k8Image, precedence)
+	r := newFieldResolver(tags, sources, defaults)
 
 	collectorImage := &CollectorImage{
 		Namespace: k8Image.NamespaceName,
 		Image:     k8Image.Image,
 		ImageId:   k8Image.ImageId,
 
-		Environment:            GetOrDefaultString(tags, annotationNames.Base+"environment", defaults.Environment),
-		Product:                GetOrDefaultString(tags, annotationNames.Base+"product", defaults.Product),
-		Description:            GetOrDefaultString(tags, annotationNames.Base+"description", defaults.Description),
-		AppKubernetesIoName:    GetOrDefaultString(tags, "app.kubernetes.io/name", ""),
-		AppKubernetesIoVersion: GetOrDefaultString(tags, "app.kubernetes.io/version", ""),
-		ContainerType:          GetOrDefaultString(tags, annotationNames.Base+"container-type", defaults.ContainerType),
-		Skip:                   GetOrDefaultBool(tags, annotationNames.Scans+"skip", defaults.Skip),
-		NamespaceFilter:        GetOrDefaultString(tags, annotationNames.Scans+"namespace-filter", defaults.NamespaceFilter),
-		NamespaceFilterNegated: GetOrDefaultString(tags, annotationNames.Scans+"negated_namespace_filter", defaults.NamespaceFilterNegated),
-		EngagementTags:         GetOrDefaultStringSlice(tags, annotationNames.DefectDojo+"engagement-tags", defaults.EngagementTags),
-
-		Team:  GetOrDefaultString(tags, annotationNames.Contact+"team", defaults.Team),
-		Slack: GetOrDefaultString(tags, annotationNames.Contact+"slack", defaults.Slack),
-		Email: GetOrDefaultString(tags, annotationNames.Contact+"email", defaults.Email),
-
-		IsScanBaseimageLifetime:          GetOrDefaultBool(tags, annotationNames.Scans+"is-scan-baseimage-lifetime", defaults.IsScanBaseimageLifetime),
-		IsScanDependencyCheck:            GetOrDefaultBool(tags, annotationNames.Scans+"is-scan-dependency-check", defaults.IsScanDependencyCheck),
-		IsScanDependencyTrack:            GetOrDefaultBool(tags, annotationNames.Scans+"is-scan-dependency-track", defaults.IsScanDependencyTrack),
-		IsScanDistroless:                 GetOrDefaultBool(tags, annotationNames.Scans+"is-scan-distroless", defaults.IsScanDistroless),
-		IsScanLifetime:                   GetOrDefaultBool(tags, annotationNames.Scans+"is-scan-lifetime", defaults.IsScanLifetime),
-		IsScanMalware:                    GetOrDefaultBool(tags, annotationNames.Scans+"is-scan-malware", defaults.IsScanMalware),
-		IsScanNewVersion:                 GetOrDefaultBool(tags, annotationNames.Scans+"is-scan-new-version", defaults.IsScanNewVersion),
-		IsScanRunAsRoot:                  GetOrDefaultBool(tags, annotationNames.Scans+"is-scan-runasroot", defaults.IsScanRunAsRoot),
-		IsPotentiallyRunningAsRoot:       GetOrDefaultBool(tags, annotationNames.Scans+"is-scan-potentially-running-as-root", defaults.IsPotentiallyRunningAsRoot),
-		IsScanRunAsPrivileged:            GetOrDefaultBool(tags, annotationNames.Scans+"is-scan-run-as-privileged", defaults.IsScanRunAsPrivileged),
-		IsPotentiallyRunningAsPrivileged: GetOrDefaultBool(tags, annotationNames.Scans+"is-scan-potentially-running-as-privileged", defaults.IsPotentiallyRunningAsPrivileged),
-		ScanLifetimeMaxDays:              GetOrDefaultInt64(tags, annotationNames.Scans+"scan-lifetime-max-days", defaults.ScanLifetimeMaxDays),
+		Pod:           k8Image.PodName,
+		ContainerName: k8Image.ContainerName,
+		ContainerRole: k8Image.ContainerRole,
+		WorkloadKind:  k8Image.WorkloadKind,
+		WorkloadName:  k8Image.WorkloadName,
+		WorkloadUid:   k8Image.WorkloadUid,
+		State:         k8Image.State,
+
+		Environment:            r.String("environment", annotationNames.Base+"environment", defaults.Environment),
+		Product:                r.String("product", annotationNames.Base+"product", defaults.Product),
+		Description:            r.String("description", annotationNames.Base+"description", defaults.Description),
+		AppKubernetesIoName:    r.String("app_kubernetes_io_name", "app.kubernetes.io/name", ""),
+		AppKubernetesIoVersion: r.String("app_kubernetes_io_version", "app.kubernetes.io/version", ""),
+		ContainerType:          r.String("container_type", annotationNames.Base+"container-type", defaults.ContainerType),
+		Skip:                   r.Bool("skip", annotationNames.Scans+"skip", defaults.Skip),
+		NamespaceFilter:        r.String("namespace_filter", annotationNames.Scans+"namespace-filter", defaults.NamespaceFilter),
+		NamespaceFilterNegated: r.String("namespace_filter_negated", annotationNames.Scans+"negated_namespace_filter", defaults.NamespaceFilterNegated),
+		EngagementTags:         r.StringSlice("engagement_tags", annotationNames.DefectDojo+"engagement-tags", defaults.EngagementTags),
+
+		Team:  r.String("team", annotationNames.Contact+"team", defaults.Team),
+		Slack: r.String("slack", annotationNames.Contact+"slack", defaults.Slack),
+		Email: r.String("email", annotationNames.Contact+"email", defaults.Email),
+
+		IsScanBaseimageLifetime:          r.Bool("is_scan_baseimage_lifetime", annotationNames.Scans+"is-scan-baseimage-lifetime", defaults.IsScanBaseimageLifetime),
+		IsScanDependencyCheck:            r.Bool("is_scan_dependency_check", annotationNames.Scans+"is-scan-dependency-check", defaults.IsScanDependencyCheck),
+		IsScanDependencyTrack:            r.Bool("is_scan_dependency_track", annotationNames.Scans+"is-scan-dependency-track", defaults.IsScanDependencyTrack),
+		IsScanDistroless:                 r.Bool("is_scan_distroless", annotationNames.Scans+"is-scan-distroless", defaults.IsScanDistroless),
+		IsScanLifetime:                   r.Bool("is_scan_lifetime", annotationNames.Scans+"is-scan-lifetime", defaults.IsScanLifetime),
+		IsScanMalware:                    r.Bool("is_scan_maleware", annotationNames.Scans+"is-scan-malware", defaults.IsScanMalware),
+		IsScanNewVersion:                 r.Bool("is_scan_new_version", annotationNames.Scans+"is-scan-new-version", defaults.IsScanNewVersion),
+		IsScanRunAsRoot:                  r.Bool("is_scan_runasroot", annotationNames.Scans+"is-scan-runasroot", defaults.IsScanRunAsRoot),
+		IsPotentiallyRunningAsRoot:       r.Bool("is_scan_potentially_running_as_root", annotationNames.Scans+"is-scan-potentially-running-as-root", defaults.IsPotentiallyRunningAsRoot),
+		IsScanRunAsPrivileged:            r.Bool("is_scan_run_as_privileged", annotationNames.Scans+"is-scan-run-as-privileged", defaults.IsScanRunAsPrivileged),
+		IsPotentiallyRunningAsPrivileged: r.Bool("is_scan_potentially_running_as_privileged", annotationNames.Scans+"is-scan-potentially-running-as-privileged", defaults.IsPotentiallyRunningAsPrivileged),
+		ScanLifetimeMaxDays:              r.Int64("scan_lifetime_max_days", annotationNames.Scans+"scan-lifetime-max-days", defaults.ScanLifetimeMaxDays),
 	}
+	collectorImage.Provenance = r.provenance
 
 	return collectorImage
 
@@ -111,29 +192,25 @@ func isSkipImage(ci *CollectorImage, imageFilter *RunConfig) bool {
 }
 
 func isSkipImageByImageFilter(ci *CollectorImage, runConfig *RunConfig) bool {
-	for _, imageFilter := range runConfig.ImageFilter {
-		log.Debug().Msgf("image %s (imagefilter %s)", ci.Image, imageFilter)
-		matched, err := regexp.MatchString(imageFilter, ci.Image)
-		if matched && err == nil {
-			return true
+	return matchImageFilter(ci, runConfig) != nil
+}
+
+// matchImageFilter returns the first image filter matching the image
+func matchImageFilter(ci *CollectorImage, runConfig *RunConfig) *ImageFilter {
+	for _, imageFilter := range runConfig.imageFilters {
+		log.Debug().Msgf("image %s (imagefilter %s)", ci.Image, imageFilter.Pattern)
+		if imageFilter.MatchString(ci.Image) {
+			return &imageFilter
 		}
 	}
 
-	return false
+	return nil
 }
 
 // considering the images labels, annotations and deployment wide defaults
 func isSkipImageByNamespace(ci *CollectorImage) bool {
-	isNamespaceFilter, _ := regexp.MatchString(ci.NamespaceFilter, ci.Namespace)
-	if ci.NamespaceFilter == "" {
-		isNamespaceFilter = false
-	}
-
-	isNamespaceFilterNegated := false
-	isNamespaceFilterMatch, _ := regexp.MatchString(ci.NamespaceFilterNegated, ci.Namespace)
-	if ci.NamespaceFilterNegated != "" {
-		isNamespaceFilterNegated = isNamespaceFilterMatch
-	}
+	isNamespaceFilter := matchNamespaceFilter(ci.NamespaceFilter, ci.Namespace)
+	isNamespaceFilterNegated := matchNamespaceFilter(ci.NamespaceFilterNegated, ci.Namespace)
 
 	return ci.Skip || isNamespaceFilter || isNamespaceFilterNegated
 }
@@ -142,6 +219,16 @@ func isSkipImageByNamespace(ci *CollectorImage) bool {
 func cleanCollectorImage(ci *CollectorImage, imageFilter *RunConfig) {
 	ci.Image = strings.Replace(ci.Image, "docker-pullable://", "", -1)
 	ci.ImageId = cleanCollectorImageId(ci)
+	setImageReference(ci)
+
+	if imageFilter.Provenance {
+		if ci.Provenance == nil {
+			ci.Provenance = map[string]Provenance{}
+		}
+		ci.Provenance["skip"] = skipProvenance(ci, imageFilter, ci.Provenance["skip"])
+	} else {
+		ci.Provenance = nil
+	}
 
 	ci.Skip = isSkipImage(ci, imageFilter)
 }
@@ -155,23 +242,67 @@ func cleanCollectorImageId(ci *CollectorImage) string {
 	return imageId
 }
 
-// images from kubernetes, convert, clean and store them in the storage
+// setImageReference sets the registry, repository, tag and digest from the image and image id
+func setImageReference(ci *CollectorImage) {
+	ref := ParseImageReference(ci.Image)
+	if ref.Digest == "" {
+		ref.Digest = digestFromImageId(ci.ImageId)
+	}
+
+	ci.Registry = ref.Registry
+	ci.Repository = ref.Repository
+	ci.Tag = ref.Tag
+	ci.Digest = ref.Digest
+}
+
+// images from kubernetes, convert, clean and store them in the storage.
+// The image filters are compiled unless they were before, an invalid filter is an error.
 func ConvertImages(k8Images *[]kubeclient.Image, defaults *CollectorImage, annotationNames *AnnotationNames, runConfig *RunConfig) (*[]CollectorImage, error) {
 	var images []CollectorImage
 
 	for _, k8Image := range *k8Images {
-		collectorImage := convertK8ImageToCollectorImage(k8Image, defaults, annotationNames)
-		cleanCollectorImage(collectorImage, runConfig)
-		images = append(images, *collectorImage)
-
+		image, err := ConvertImage(k8Image, defaults, annotationNames, runConfig)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, *image)
 	}
 
 	return &images, nil
 }
 
+// ConvertImage converts and cleans a single image from kubernetes, e.g. while the images are streamed by the kubeclient.
+// The image filters are compiled unless they were before, an invalid filter is an error.
+func ConvertImage(k8Image kubeclient.Image, defaults *CollectorImage, annotationNames *AnnotationNames, runConfig *RunConfig) (*CollectorImage, error) {
+	if err := runConfig.compileImageFiltersOnce(); err != nil {
+		return nil, err
+	}
+
+	teamDefaults := runConfig.NamespaceToTeam.Defaults(k8Image.NamespaceName, defaults)
+	collectorImage := convertK8ImageToCollectorImage(k8Image, teamDefaults, annotationNames, runConfig.Precedence)
+	if k8Image.Error != "" {
+		markCollectorImageError(collectorImage, k8Image.Error, runConfig)
+		return collectorImage, nil
+	}
+	cleanCollectorImage(collectorImage, runConfig)
+	return collectorImage, nil
+}
+
+// markCollectorImageError reports the namespace as not collected and skips it
+func markCollectorImageError(ci *CollectorImage, err string, runConfig *RunConfig) {
+	ci.Error = err
+	ci.Skip = true
+
+	if runConfig.Provenance {
+		ci.Provenance["skip"] = Provenance{Source: SourceError, Key: err}
+	} else {
+		ci.Provenance = nil
+	}
+}
+
 // TODO: Write Tests. Not written yet due to upcomming refactor
-// stores images in the provided storager implementation
-func Store(images *[]CollectorImage, storage io.Writer, jsonMarshal JsonMarshal) error {
+// stores images (flat or aggregated) in the provided storager implementation
+func Store[T CollectorImage | AggregatedImage](images *[]T, storage io.Writer, jsonMarshal JsonMarshal) error {
 
 	if images == nil {
 		err := errors.New("cannot marshal nil")
@@ -206,6 +206,7 @@ func TestIsSkipByImageFilter(t *testing.T) {
 			runConfig := RunConfig{
 				ImageFilter: tc.imageFilter,
 			}
+			assert.NoError(t, runConfig.CompileImageFilters())
 			result := isSkipImageByImageFilter(&tc.targetImage, &runConfig)
 
 			assert.Equal(t, result, tc.expectedResult, "Expected %v, got %v, with Namespace=%s, Skip=%v, NamespaceFilter=%v, NamespaceFilterNegated=%v, imageFilter=\"%v\"",
@@ -483,9 +484,12 @@ func TestConvert(t *testing.T) {
 				NamespaceName: "myNamespace",
 			}},
 			expectedCollectorImage: &[]CollectorImage{{
-				Namespace: "myNamespace",
-				Image:     "quay.io/name:tag",
-				ImageId:   "quay.io/name:tag",
+				Namespace:  "myNamespace",
+				Image:      "quay.io/name:tag",
+				ImageId:    "quay.io/name:tag",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag",
 			}},
 		},
 		{
@@ -504,9 +508,12 @@ func TestConvert(t *testing.T) {
 				NamespaceName: "myNamespace",
 			}},
 			expectedCollectorImage: &[]CollectorImage{{
-				Namespace: "myNamespace",
-				Image:     "quay.io/name:tag",
-				ImageId:   "quay.io/name:tag",
+				Namespace:  "myNamespace",
+				Image:      "quay.io/name:tag",
+				ImageId:    "quay.io/name:tag",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag",
 
 				Environment:    defaults.Environment,
 				ContainerType:  defaults.ContainerType,
@@ -533,9 +540,12 @@ func TestConvert(t *testing.T) {
 				NamespaceName: "myNamespace",
 			}},
 			expectedCollectorImage: &[]CollectorImage{{
-				Namespace: "myNamespace",
-				Image:     "quay.io/name:tag1",
-				ImageId:   "quay.io/name:tag1",
+				Namespace:  "myNamespace",
+				Image:      "quay.io/name:tag1",
+				ImageId:    "quay.io/name:tag1",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag1",
 
 				Environment:    defaults.Environment,
 				ContainerType:  defaults.ContainerType,
@@ -549,9 +559,12 @@ func TestConvert(t *testing.T) {
 				IsScanLifetime:          defaults.IsScanLifetime,
 				IsScanMalware:           defaults.IsScanMalware,
 			}, {
-				Namespace: "myNamespace",
-				Image:     "quay.io/name:tag2",
-				ImageId:   "quay.io/name:tag2",
+				Namespace:  "myNamespace",
+				Image:      "quay.io/name:tag2",
+				ImageId:    "quay.io/name:tag2",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag2",
 
 				Environment:    defaults.Environment,
 				ContainerType:  defaults.ContainerType,
@@ -576,9 +589,12 @@ func TestConvert(t *testing.T) {
 				Labels:        map[string]string{"contact.sda.se/team": "some-none-default-team"},
 			}},
 			expectedCollectorImage: &[]CollectorImage{{
-				Namespace: "myNamespace",
-				Image:     "quay.io/name:tag",
-				ImageId:   "quay.io/name:tag",
+				Namespace:  "myNamespace",
+				Image:      "quay.io/name:tag",
+				ImageId:    "quay.io/name:tag",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag",
 
 				Environment:    defaults.Environment,
 				ContainerType:  defaults.ContainerType,
@@ -603,9 +619,12 @@ func TestConvert(t *testing.T) {
 				Annotations:   map[string]string{"contact.sda.se/team": "some-none-default-team"},
 			}},
 			expectedCollectorImage: &[]CollectorImage{{
-				Namespace: "myNamespace",
-				Image:     "quay.io/name:tag",
-				ImageId:   "quay.io/name:tag",
+				Namespace:  "myNamespace",
+				Image:      "quay.io/name:tag",
+				ImageId:    "quay.io/name:tag",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag",
 
 				Environment:    defaults.Environment,
 				ContainerType:  defaults.ContainerType,
@@ -631,9 +650,12 @@ func TestConvert(t *testing.T) {
 				Annotations:   map[string]string{"contact.sda.se/team": "team-from-annotations"},
 			}},
 			expectedCollectorImage: &[]CollectorImage{{
-				Namespace: "myNamespace",
-				Image:     "quay.io/name:tag",
-				ImageId:   "quay.io/name:tag",
+				Namespace:  "myNamespace",
+				Image:      "quay.io/name:tag",
+				ImageId:    "quay.io/name:tag",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag",
 
 				Environment:    defaults.Environment,
 				ContainerType:  defaults.ContainerType,
@@ -660,9 +682,13 @@ func TestConvert(t *testing.T) {
 				Labels:        map[string]string{"contact.sda.se/team": "some-none-default-team"},
 			}},
 			expectedCollectorImage: &[]CollectorImage{{
-				Namespace: "myNamespace",
-				Image:     "quay.io/name:tag",
-				ImageId:   "quay.io/name@sha256:1234",
+				Namespace:  "myNamespace",
+				Image:      "quay.io/name:tag",
+				ImageId:    "quay.io/name@sha256:1234",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag",
+				Digest:     "sha256:1234",
 
 				Environment:    defaults.Environment,
 				ContainerType:  defaults.ContainerType,
@@ -688,9 +714,13 @@ func TestConvert(t *testing.T) {
 				Annotations:   map[string]string{"dd.sda.se/engagement-tags": "first,second,third"},
 			}},
 			expectedCollectorImage: &[]CollectorImage{{
-				Namespace: "myNamespace",
-				Image:     "quay.io/name:tag",
-				ImageId:   "quay.io/name@sha256:1234",
+				Namespace:  "myNamespace",
+				Image:      "quay.io/name:tag",
+				ImageId:    "quay.io/name@sha256:1234",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag",
+				Digest:     "sha256:1234",
 
 				Environment:    defaults.Environment,
 				ContainerType:  defaults.ContainerType,
@@ -715,9 +745,12 @@ func TestConvert(t *testing.T) {
 				Annotations:   map[string]string{"wrong-name.sda.se/team": "team-from-annotations"},
 			}},
 			expectedCollectorImage: &[]CollectorImage{{
-				Namespace: "myNamespace",
-				Image:     "quay.io/name:tag",
-				ImageId:   "quay.io/name:tag",
+				Namespace:  "myNamespace",
+				Image:      "quay.io/name:tag",
+				ImageId:    "quay.io/name:tag",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag",
 
 				Environment:    defaults.Environment,
 				ContainerType:  defaults.ContainerType,
@@ -743,9 +776,12 @@ func TestConvert(t *testing.T) {
 				Annotations:   map[string]string{"sda.se/description": "Lorem Ipsum Dolor Sit Amet"},
 			}},
 			expectedCollectorImage: &[]CollectorImage{{
-				Namespace: "myNamespace",
-				Image:     "quay.io/name:tag",
-				ImageId:   "quay.io/name:sha",
+				Namespace:  "myNamespace",
+				Image:      "quay.io/name:tag",
+				ImageId:    "quay.io/name:sha",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag",
 
 				Environment:    defaults.Environment,
 				Description:    "Lorem Ipsum Dolor Sit Amet",
@@ -785,9 +821,13 @@ func TestConvert(t *testing.T) {
 				Labels:        map[string]string{"contact.sda.se/team": "team-3"},
 			}},
 			expectedCollectorImage: &[]CollectorImage{{
-				Namespace: "myNamespace-1",
-				Image:     "quay.io/name:tag-1",
-				ImageId:   "quay.io/name@sha256:1234",
+				Namespace:  "myNamespace-1",
+				Image:      "quay.io/name:tag-1",
+				ImageId:    "quay.io/name@sha256:1234",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag-1",
+				Digest:     "sha256:1234",
 
 				Environment:    defaults.Environment,
 				ContainerType:  defaults.ContainerType,
@@ -801,9 +841,13 @@ func TestConvert(t *testing.T) {
 				IsScanLifetime:          defaults.IsScanLifetime,
 				IsScanMalware:           false,
 			}, {
-				Namespace: "myNamespace-1",
-				Image:     "quay.io/name:tag-2",
-				ImageId:   "quay.io/name@sha256:2222",
+				Namespace:  "myNamespace-1",
+				Image:      "quay.io/name:tag-2",
+				ImageId:    "quay.io/name@sha256:2222",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag-2",
+				Digest:     "sha256:2222",
 
 				Environment:    defaults.Environment,
 				ContainerType:  defaults.ContainerType,
@@ -817,9 +861,13 @@ func TestConvert(t *testing.T) {
 				IsScanLifetime:          defaults.IsScanLifetime,
 				IsScanMalware:           true,
 			}, {
-				Namespace: "myNamespace-2",
-				Image:     "quay.io/name:tag-3",
-				ImageId:   "quay.io/name@sha256:3333",
+				Namespace:  "myNamespace-2",
+				Image:      "quay.io/name:tag-3",
+				ImageId:    "quay.io/name@sha256:3333",
+				Registry:   "quay.io",
+				Repository: "name",
+				Tag:        "tag-3",
+				Digest:     "sha256:3333",
 
 				Environment:    defaults.Environment,
 				ContainerType:  defaults.ContainerType,
@@ -834,6 +882,33 @@ func TestConvert(t *testing.T) {
 				IsScanMalware:           false,
 			}},
 		},
+		{
+			name:            "ContainerAndWorkloadAreCopied",
+			defaults:        &CollectorImage{},
+			annotationNames: &AnnotationNames{},
+			targetK8Image: &[]kubeclient.Image{{
+				Image:         "quay.io/name:tag",
+				NamespaceName: "myNamespace",
+				ContainerName: "my-container",
+				ContainerRole: "init",
+				WorkloadKind:  "Deployment",
+				WorkloadName:  "my-deployment",
+				WorkloadUid:   "1234",
+			}},
+			expectedCollectorImage: &[]CollectorImage{{
+				Namespace:     "myNamespace",
+				Image:         "quay.io/name:tag",
+				ImageId:       "quay.io/name:tag",
+				Registry:      "quay.io",
+				Repository:    "name",
+				Tag:           "tag",
+				ContainerName: "my-container",
+				ContainerRole: "init",
+				WorkloadKind:  "Deployment",
+				WorkloadName:  "my-deployment",
+				WorkloadUid:   "1234",
+			}},
+		},
 	}
 	runConfig := RunConfig{
 		ImageFilter: []string{},
@@ -959,3 +1034,45 @@ func TestStore(t *testing.T) {
 	}
 
 }
+
+func TestConvertImageError(t *testing.T) {
+	k8Image := kubeclient.Image{
+		NamespaceName:   "mordor",
+		NamespaceLabels: map[string]string{"contact.sda.se/team": "nazgul"},
+		Error:           "pods is forbidden",
+	}
+	annotationNames := AnnotationNames{Contact: "contact.sda.se/"}
+
+	image, err := ConvertImage(k8Image, &CollectorImage{Environment: "middle-earth"}, &annotationNames, &RunConfig{Precedence: DefaultPrecedence, Provenance: true})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "mordor", image.Namespace)
+	assert.Equal(t, "middle-earth", image.Environment)
+	assert.Equal(t, "nazgul", image.Team)
+	assert.Equal(t, "pods is forbidden", image.Error)
+	assert.True(t, image.Skip)
+	assert.Equal(t, Provenance{Source: SourceError, Key: "pods is forbidden"}, image.Provenance["skip"])
+
+	image, err = ConvertImage(k8Image, &CollectorImage{}, &annotationNames, &RunConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, image.Provenance)
+}
+
+func TestConvertImageState(t *testing.T) {
+	k8Image := kubeclient.Image{
+		Image:         "sidecar:2.0",
+		NamespaceName: "rivendell",
+		Annotations:   map[string]string{"contact.sda.se/team": "fellowship"},
+		WorkloadKind:  "Deployment",
+		WorkloadName:  "elrond",
+		State:         kubeclient.ImageStateDeclared,
+	}
+	annotationNames := AnnotationNames{Contact: "contact.sda.se/"}
+
+	image, err := ConvertImage(k8Image, &CollectorImage{}, &annotationNames, &RunConfig{Precedence: DefaultPrecedence})
+	assert.NoError(t, err)
+
+	assert.Equal(t, kubeclient.ImageStateDeclared, image.State)
+	assert.Equal(t, "fellowship", image.Team)
+	assert.Empty(t, image.Pod)
+}
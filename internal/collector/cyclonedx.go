@@ -0,0 +1,237 @@
+package collector
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// CycloneDX output formats, see https://cyclonedx.org/docs/1.5/
+const (
+	OutputFormatCycloneDXJSON = "cyclonedx-json"
+	OutputFormatCycloneDXXML  = "cyclonedx-xml"
+)
+
+// toolName is the name of the collector in generated documents
+const toolName = "image-metadata-collector"
+
+// cycloneDXProperties are the json fields of the images carried as properties of their component
+var cycloneDXProperties = []string{
+	"cluster", "namespace", "environment", "team", "product", "slack", "email", "container_type", "engagement_tags", "skip",
+	"is_scan_baseimage_lifetime", "is_scan_dependency_check", "is_scan_dependency_track", "is_scan_distroless",
+	"is_scan_lifetime", "is_scan_maleware", "is_scan_new_version", "is_scan_runasroot",
+	"is_scan_potentially_running_as_root", "is_scan_run_as_privileged", "is_scan_potentially_running_as_privileged",
+	"scan_lifetime_max_days",
+}
+
+// cycloneDXPropertyPrefix namespaces the properties, e.g. image-metadata-collector:team
+const cycloneDXPropertyPrefix = toolName + ":"
+
+type cdxBOM struct {
+	XMLName     xml.Name       `json:"-" xml:"bom"`
+	XMLNS       string         `json:"-" xml:"xmlns,attr"`
+	BOMFormat   string         `json:"bomFormat" xml:"-"`
+	SpecVersion string         `json:"specVersion" xml:"-"`
+	Version     int            `json:"version" xml:"version,attr"`
+	Metadata    cdxMetadata    `json:"metadata" xml:"metadata"`
+	Components  []cdxComponent `json:"components" xml:"components>component"`
+}
+
+type cdxMetadata struct {
+	Tools cdxTools `json:"tools" xml:"tools"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components" xml:"components>component"`
+}
+
+type cdxComponent struct {
+	Type       string        `json:"type" xml:"type,attr"`
+	BOMRef     string        `json:"bom-ref,omitempty" xml:"bom-ref,attr,omitempty"`
+	Name       string        `json:"name" xml:"name"`
+	Version    string        `json:"version,omitempty" xml:"version,omitempty"`
+	Hashes     cdxHashes     `json:"hashes,omitempty" xml:"hashes,omitempty"`
+	PURL       string        `json:"purl,omitempty" xml:"purl,omitempty"`
+	Properties cdxProperties `json:"properties,omitempty" xml:"properties,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg" xml:"alg,attr"`
+	Content string `json:"content" xml:",chardata"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name" xml:"name,attr"`
+	Value string `json:"value" xml:",chardata"`
+}
+
+// cdxHashes and cdxProperties are wrapped in an element in XML, which is left out if empty unlike a>b paths
+type cdxHashes []cdxHash
+type cdxProperties []cdxProperty
+
+type cdxHashesElement struct {
+	Hashes []cdxHash `xml:"hash"`
+}
+
+type cdxPropertiesElement struct {
+	Properties []cdxProperty `xml:"property"`
+}
+
+func (h cdxHashes) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(cdxHashesElement{Hashes: h}, start)
+}
+
+func (h *cdxHashes) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var element cdxHashesElement
+	err := d.DecodeElement(&element, &start)
+	*h = element.Hashes
+	return err
+}
+
+func (p cdxProperties) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(cdxPropertiesElement{Properties: p}, start)
+}
+
+func (p *cdxProperties) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var element cdxPropertiesElement
+	err := d.DecodeElement(&element, &start)
+	*p = element.Properties
+	return err
+}
+
+func init() {
+	RegisterEncoder(OutputFormatCycloneDXJSON, func(options *EncoderOptions) (Encoder, error) {
+		return Encoder{Extension: ".cdx.json", Marshal: func(v any) ([]byte, error) {
+			bom, err := newCycloneDXBOM(v)
+			if err != nil {
+				return nil, err
+			}
+			return jsonIndentMarshalUnescaped(bom)
+		}}, nil
+	})
+	RegisterEncoder(OutputFormatCycloneDXXML, func(options *EncoderOptions) (Encoder, error) {
+		return Encoder{Extension: ".cdx.xml", Marshal: func(v any) ([]byte, error) {
+			bom, err := newCycloneDXBOM(v)
+			if err != nil {
+				return nil, err
+			}
+			data, err := xml.MarshalIndent(bom, "", "\t")
+			if err != nil {
+				return nil, err
+			}
+			return append([]byte(xml.Header), data...), nil
+		}}, nil
+	})
+}
+
+// storedImages returns the images passed to Store, aggregated images without their locations
+func storedImages(v any) ([]CollectorImage, error) {
+	switch images := v.(type) {
+	case *[]CollectorImage:
+		return *images, nil
+	case *[]AggregatedImage:
+		var collectorImages []CollectorImage
+		for _, image := range *images {
+			collectorImages = append(collectorImages, image.CollectorImage)
+		}
+		return collectorImages, nil
+	}
+	return nil, fmt.Errorf("%T can't be encoded", v)
+}
+
+// newCycloneDXBOM describes every image as a container component, images which could not be collected are left out.
+// The BOM has neither serial number nor timestamp, so unchanged images result in the same BOM.
+func newCycloneDXBOM(v any) (*cdxBOM, error) {
+	images, err := storedImages(v)
+	if err != nil {
+		return nil, err
+	}
+
+	bom := &cdxBOM{
+		XMLNS:       "http://cyclonedx.org/schema/bom/1.5",
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Metadata:    cdxMetadata{Tools: cdxTools{Components: []cdxComponent{{Type: "application", Name: toolName}}}},
+		Components:  []cdxComponent{},
+	}
+
+	for i, image := range images {
+		if image.Error != "" {
+			continue
+		}
+		component, err := newCycloneDXComponent(&image)
+		if err != nil {
+			return nil, err
+		}
+		// Images are listed per location, so the same image may be part of the BOM several times
+		component.BOMRef = fmt.Sprintf("image-%d", i+1)
+		bom.Components = append(bom.Components, component)
+	}
+
+	return bom, nil
+}
+
+func newCycloneDXComponent(image *CollectorImage) (cdxComponent, error) {
+	ref := ImageReference{Registry: image.Registry, Repository: image.Repository, Tag: image.Tag, Digest: image.Digest}
+	component := cdxComponent{
+		Type:    "container",
+		Name:    image.Image,
+		Version: image.Tag,
+		PURL:    ref.PackageURL(),
+	}
+	if image.Repository != "" {
+		component.Name = image.Registry + "/" + image.Repository
+	}
+	if hash, ok := digestHash(image.Digest); ok {
+		component.Hashes = cdxHashes{hash}
+	}
+
+	fields, err := jsonFields(image)
+	if err != nil {
+		return component, err
+	}
+	for _, name := range cycloneDXProperties {
+		value := propertyValue(fields[name])
+		if value == "" {
+			continue
+		}
+		component.Properties = append(component.Properties, cdxProperty{Name: cycloneDXPropertyPrefix + name, Value: value})
+	}
+
+	return component, nil
+}
+
+// digestHash returns the hash of a digest, e.g. sha256:abc is the SHA-256 hash abc
+func digestHash(digest string) (cdxHash, bool) {
+	algorithm, content, found := strings.Cut(digest, ":")
+	if !found {
+		return cdxHash{}, false
+	}
+	switch algorithm {
+	case "sha256":
+		return cdxHash{Alg: "SHA-256", Content: content}, true
+	case "sha384":
+		return cdxHash{Alg: "SHA-384", Content: content}, true
+	case "sha512":
+		return cdxHash{Alg: "SHA-512", Content: content}, true
+	}
+	return cdxHash{}, false
+}
+
+// propertyValue formats a json field, lists are joined by commas
+func propertyValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		var values []string
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return strings.Join(values, ",")
+	}
+	return fmt.Sprint(value)
+}
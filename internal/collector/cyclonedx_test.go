@@ -0,0 +1,79 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var cycloneDXImages = []CollectorImage{
+	{
+		Namespace: "shire", Image: "quay.io/sdase/ring:1.0", Registry: "quay.io", Repository: "sdase/ring", Tag: "1.0", Digest: "sha256:abcd",
+		Environment: "middle-earth", Team: "hobbits", Product: "ring", EngagementTags: []string{"a", "b"}, IsScanLifetime: true, ScanLifetimeMaxDays: 120,
+	},
+	{Namespace: "mordor", Error: "pods is forbidden", Skip: true},
+}
+
+func TestCycloneDXJSON(t *testing.T) {
+	encoder, err := NewEncoder(OutputFormatCycloneDXJSON, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, ".cdx.json", encoder.Extension)
+
+	var data bytes.Buffer
+	assert.NoError(t, Store(&cycloneDXImages, &data, encoder.Marshal))
+
+	assert.Contains(t, data.String(), "&tag=1.0", "Expected the purl not to be escaped")
+
+	var bom cdxBOM
+	assert.NoError(t, json.Unmarshal(data.Bytes(), &bom))
+	assert.Equal(t, "CycloneDX", bom.BOMFormat)
+	assert.Equal(t, "1.5", bom.SpecVersion)
+	assert.Len(t, bom.Components, 1, "Expected images which could not be collected to be left out")
+
+	component := bom.Components[0]
+	assert.Equal(t, "container", component.Type)
+	assert.Equal(t, "quay.io/sdase/ring", component.Name)
+	assert.Equal(t, "1.0", component.Version)
+	assert.Equal(t, "pkg:oci/ring@sha256%3Aabcd?repository_url=quay.io/sdase/ring&tag=1.0", component.PURL)
+	assert.Equal(t, cdxHashes{{Alg: "SHA-256", Content: "abcd"}}, component.Hashes)
+
+	properties := map[string]string{}
+	for _, property := range component.Properties {
+		properties[property.Name] = property.Value
+	}
+	assert.Equal(t, "shire", properties["image-metadata-collector:namespace"])
+	assert.Equal(t, "middle-earth", properties["image-metadata-collector:environment"])
+	assert.Equal(t, "hobbits", properties["image-metadata-collector:team"])
+	assert.Equal(t, "ring", properties["image-metadata-collector:product"])
+	assert.Equal(t, "a,b", properties["image-metadata-collector:engagement_tags"])
+	assert.Equal(t, "true", properties["image-metadata-collector:is_scan_lifetime"])
+	assert.Equal(t, "false", properties["image-metadata-collector:skip"])
+	assert.Equal(t, "120", properties["image-metadata-collector:scan_lifetime_max_days"])
+	assert.NotContains(t, properties, "image-metadata-collector:cluster")
+}
+
+func TestCycloneDXXML(t *testing.T) {
+	encoder, err := NewEncoder(OutputFormatCycloneDXXML, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, ".cdx.xml", encoder.Extension)
+
+	aggregated, err := AggregateImages(&cycloneDXImages)
+	assert.NoError(t, err)
+
+	var data bytes.Buffer
+	assert.NoError(t, Store(aggregated, &data, encoder.Marshal))
+	assert.True(t, strings.HasPrefix(data.String(), xml.Header+`<bom xmlns="http://cyclonedx.org/schema/bom/1.5" version="1">`))
+	assert.Contains(t, data.String(), `<component type="container" bom-ref="image-1">`)
+	assert.Contains(t, data.String(), `<hash alg="SHA-256">abcd</hash>`)
+	assert.Contains(t, data.String(), `<property name="image-metadata-collector:team">hobbits</property>`)
+	assert.NotContains(t, data.String(), "<hashes></hashes>", "Expected no empty hashes of the tool")
+
+	var bom cdxBOM
+	assert.NoError(t, xml.Unmarshal(data.Bytes(), &bom))
+	assert.Len(t, bom.Components, 1)
+	assert.Equal(t, "pkg:oci/ring@sha256%3Aabcd?repository_url=quay.io/sdase/ring&tag=1.0", bom.Components[0].PURL)
+}
@@ -0,0 +1,150 @@
+package collector
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// FieldChange is a field of an image which changed since the previous run, by its json name
+type FieldChange struct {
+	Field    string `json:"field"`
+	Previous any    `json:"previous"`
+	Current  any    `json:"current"`
+}
+
+// ChangedImage is an image which exists in both runs with different settings
+type ChangedImage struct {
+	CollectorImage
+
+	Changes []FieldChange `json:"changes"`
+}
+
+// Delta lists the images added, removed or changed since the previous run.
+// Images are identified by an ImageKey and listed without their location, see ImageLocation.
+type Delta struct {
+	Added   []CollectorImage `json:"added"`
+	Removed []CollectorImage `json:"removed"`
+	Changed []ChangedImage   `json:"changed"`
+}
+
+// ImageKey identifies an image across runs or clusters
+type ImageKey func(ci *CollectorImage) string
+
+// KeyByImageAndDigest compares images by cluster, namespace, image and digest, a new tag or digest is a different image
+func KeyByImageAndDigest(ci *CollectorImage) string {
+	return ci.Cluster + "|" + ci.Namespace + "|" + ci.Image + "|" + ci.Digest
+}
+
+// KeyByDigest compares images by cluster, namespace, registry, repository and digest, ignoring the tag.
+// Images without a digest, e.g. declared ones, are compared by their tag or, without a tag, by their image.
+func KeyByDigest(ci *CollectorImage) string {
+	if ci.Digest == "" {
+		if ci.Tag == "" {
+			return ci.Cluster + "|" + ci.Namespace + "|" + ci.Image
+		}
+		return KeyByTag(ci)
+	}
+	return ci.Cluster + "|" + ci.Namespace + "|" + ci.Registry + "/" + ci.Repository + "@" + ci.Digest
+}
+
+// KeyByTag compares images by cluster, namespace, registry, repository and tag, a new digest is a changed image
+func KeyByTag(ci *CollectorImage) string {
+	return ci.Cluster + "|" + ci.Namespace + "|" + ci.Registry + "/" + ci.Repository + ":" + ci.Tag
+}
+
+// uniqueImages returns the first occurrence of every image without its location, in the order of the images
+func uniqueImages(images *[]CollectorImage, imageKey ImageKey) ([]string, map[string]CollectorImage) {
+	var keys []string
+	byKey := map[string]CollectorImage{}
+
+	if images == nil {
+		return keys, byKey
+	}
+
+	for _, image := range *images {
+		ci, _ := splitLocation(image)
+		ci.Provenance = nil
+
+		key := imageKey(&ci)
+		if _, ok := byKey[key]; ok {
+			continue
+		}
+		keys = append(keys, key)
+		byKey[key] = ci
+	}
+	return keys, byKey
+}
+
+// DiffImages returns the images added, removed or changed between the previous and the current images
+func DiffImages(previous, current *[]CollectorImage, imageKey ImageKey) (*Delta, error) {
+	delta := &Delta{Added: []CollectorImage{}, Removed: []CollectorImage{}, Changed: []ChangedImage{}}
+
+	previousKeys, previousByKey := uniqueImages(previous, imageKey)
+	currentKeys, currentByKey := uniqueImages(current, imageKey)
+
+	for _, key := range currentKeys {
+		currentImage := currentByKey[key]
+		previousImage, ok := previousByKey[key]
+		if !ok {
+			delta.Added = append(delta.Added, currentImage)
+			continue
+		}
+
+		changes, err := diffFields(&previousImage, &currentImage)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) > 0 {
+			delta.Changed = append(delta.Changed, ChangedImage{CollectorImage: currentImage, Changes: changes})
+		}
+	}
+
+	for _, key := range previousKeys {
+		if _, ok := currentByKey[key]; !ok {
+			delta.Removed = append(delta.Removed, previousByKey[key])
+		}
+	}
+
+	return delta, nil
+}
+
+// diffFields compares the json representation of the images, so fields are named like in the output
+func diffFields(previous, current *CollectorImage) ([]FieldChange, error) {
+	previousFields, err := jsonFields(previous)
+	if err != nil {
+		return nil, err
+	}
+	currentFields, err := jsonFields(current)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for name := range previousFields {
+		names[name] = true
+	}
+	for name := range currentFields {
+		names[name] = true
+	}
+
+	var changes []FieldChange
+	for name := range names {
+		if !reflect.DeepEqual(previousFields[name], currentFields[name]) {
+			changes = append(changes, FieldChange{Field: name, Previous: previousFields[name], Current: currentFields[name]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes, nil
+}
+
+func jsonFields(ci *CollectorImage) (map[string]any, error) {
+	data, err := json.Marshal(ci)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
@@ -0,0 +1,96 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffImages(t *testing.T) {
+	previous := []CollectorImage{
+		{Namespace: "shire", Image: "hobbit:1", Digest: "sha256:1", Team: "hobbits", Pod: "frodo"},
+		{Namespace: "shire", Image: "hobbit:1", Digest: "sha256:1", Team: "hobbits", Pod: "sam"},
+		{Namespace: "shire", Image: "pipe:1", Digest: "sha256:2", Team: "hobbits", Skip: false},
+		{Namespace: "mordor", Image: "orc:1", Digest: "sha256:3", Team: "orcs"},
+	}
+	current := []CollectorImage{
+		// Moved to another pod, the location is not part of the delta
+		{Namespace: "shire", Image: "hobbit:1", Digest: "sha256:1", Team: "hobbits", Pod: "bilbo"},
+		{Namespace: "shire", Image: "pipe:1", Digest: "sha256:2", Team: "gandalf", Skip: true, Provenance: map[string]Provenance{"team": {Source: SourceDefault}}},
+		// Same image with a new digest
+		{Namespace: "mordor", Image: "orc:1", Digest: "sha256:4", Team: "orcs", Pod: "orc-1"},
+	}
+
+	delta, err := DiffImages(&previous, &current, KeyByImageAndDigest)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []CollectorImage{{Namespace: "mordor", Image: "orc:1", Digest: "sha256:4", Team: "orcs"}}, delta.Added)
+	assert.Equal(t, []CollectorImage{{Namespace: "mordor", Image: "orc:1", Digest: "sha256:3", Team: "orcs"}}, delta.Removed)
+	assert.Equal(t, []ChangedImage{{
+		CollectorImage: CollectorImage{Namespace: "shire", Image: "pipe:1", Digest: "sha256:2", Team: "gandalf", Skip: true},
+		Changes: []FieldChange{
+			{Field: "skip", Previous: false, Current: true},
+			{Field: "team", Previous: "hobbits", Current: "gandalf"},
+		},
+	}}, delta.Changed)
+}
+
+func TestDiffImagesWithoutPrevious(t *testing.T) {
+	current := []CollectorImage{{Namespace: "shire", Image: "hobbit:1"}}
+
+	delta, err := DiffImages(nil, &current, KeyByImageAndDigest)
+	assert.NoError(t, err)
+	assert.Equal(t, current, delta.Added)
+	assert.Empty(t, delta.Removed)
+	assert.Empty(t, delta.Changed)
+
+	delta, err = DiffImages(&current, &current, KeyByImageAndDigest)
+	assert.NoError(t, err)
+	assert.Equal(t, &Delta{Added: []CollectorImage{}, Removed: []CollectorImage{}, Changed: []ChangedImage{}}, delta)
+}
+
+func TestImageKeys(t *testing.T) {
+	a := CollectorImage{Namespace: "shire", Image: "quay.io/hobbit:1", Registry: "quay.io", Repository: "hobbit", Tag: "1", Digest: "sha256:1"}
+	retagged := CollectorImage{Namespace: "shire", Image: "quay.io/hobbit:2", Registry: "quay.io", Repository: "hobbit", Tag: "2", Digest: "sha256:1"}
+	rebuilt := CollectorImage{Namespace: "shire", Image: "quay.io/hobbit:1", Registry: "quay.io", Repository: "hobbit", Tag: "1", Digest: "sha256:2"}
+
+	assert.NotEqual(t, KeyByImageAndDigest(&a), KeyByImageAndDigest(&retagged))
+	assert.NotEqual(t, KeyByImageAndDigest(&a), KeyByImageAndDigest(&rebuilt))
+
+	assert.Equal(t, KeyByDigest(&a), KeyByDigest(&retagged))
+	assert.NotEqual(t, KeyByDigest(&a), KeyByDigest(&rebuilt))
+
+	assert.NotEqual(t, KeyByTag(&a), KeyByTag(&retagged))
+	assert.Equal(t, KeyByTag(&a), KeyByTag(&rebuilt))
+
+	withoutDigest := CollectorImage{Namespace: "shire", Image: "quay.io/hobbit:1", Registry: "quay.io", Repository: "hobbit", Tag: "1"}
+	retaggedWithoutDigest := CollectorImage{Namespace: "shire", Image: "quay.io/hobbit:2", Registry: "quay.io", Repository: "hobbit", Tag: "2"}
+	withoutTag := CollectorImage{Namespace: "shire", Image: "hobbit"}
+	otherWithoutTag := CollectorImage{Namespace: "shire", Image: "elf"}
+	assert.NotEqual(t, KeyByDigest(&withoutDigest), KeyByDigest(&retaggedWithoutDigest))
+	assert.NotEqual(t, KeyByDigest(&withoutTag), KeyByDigest(&otherWithoutTag))
+	assert.NotEqual(t, KeyByDigest(&a), KeyByDigest(&withoutDigest))
+
+	otherCluster := a
+	otherCluster.Cluster = "prod"
+	for _, key := range []ImageKey{KeyByImageAndDigest, KeyByDigest, KeyByTag} {
+		assert.NotEqual(t, key(&a), key(&otherCluster))
+	}
+}
+
+func TestDiffImagesWithoutDigest(t *testing.T) {
+	previous := []CollectorImage{
+		{Namespace: "shire", Image: "quay.io/hobbit:1", Registry: "quay.io", Repository: "hobbit", Tag: "1"},
+		{Namespace: "shire", Image: "quay.io/pipe:1", Registry: "quay.io", Repository: "pipe", Tag: "1"},
+	}
+	current := []CollectorImage{
+		{Namespace: "shire", Image: "quay.io/hobbit:1", Registry: "quay.io", Repository: "hobbit", Tag: "1"},
+		{Namespace: "shire", Image: "quay.io/hobbit:2", Registry: "quay.io", Repository: "hobbit", Tag: "2"},
+	}
+
+	delta, err := DiffImages(&previous, &current, KeyByDigest)
+	assert.NoError(t, err)
+	assert.Equal(t, []CollectorImage{current[1]}, delta.Added)
+	assert.Equal(t, []CollectorImage{previous[1]}, delta.Removed)
+	assert.Empty(t, delta.Changed)
+}
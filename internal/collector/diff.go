@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Keys to compare images of two inventories by, see ImageKey
+const (
+	CompareByDigest = "digest"
+	CompareByTag    = "tag"
+)
+
+// ImageKeyFor returns the ImageKey to compare images by
+func ImageKeyFor(compareBy string) (ImageKey, error) {
+	switch compareBy {
+	case CompareByDigest:
+		return KeyByDigest, nil
+	case CompareByTag:
+		return KeyByTag, nil
+	}
+	return nil, fmt.Errorf("comparing images by %s is not supported", compareBy)
+}
+
+// DiffReport compares the images of two inventories A and B, e.g. of two clusters or two days.
+// Changed images are listed with the values of B, the changes are from A (previous) to B (current).
+type DiffReport struct {
+	A       string           `json:"a"`
+	B       string           `json:"b"`
+	OnlyInA []CollectorImage `json:"only_in_a"`
+	OnlyInB []CollectorImage `json:"only_in_b"`
+	Changed []ChangedImage   `json:"changed"`
+}
+
+// NewDiffReport compares the images of the inventories a and b
+func NewDiffReport(a string, imagesA *[]CollectorImage, b string, imagesB *[]CollectorImage, imageKey ImageKey) (*DiffReport, error) {
+	delta, err := DiffImages(imagesA, imagesB, imageKey)
+	if err != nil {
+		return nil, err
+	}
+	return &DiffReport{A: a, B: b, OnlyInA: delta.Removed, OnlyInB: delta.Added, Changed: delta.Changed}, nil
+}
+
+// ReadInventory parses the images stored by Store, flat or aggregated and optionally wrapped in an Envelope
+func ReadInventory(data []byte) (*[]CollectorImage, error) {
+	if images, ok, err := readEnvelope(data); ok {
+		return images, err
+	}
+
+	var images []CollectorImage
+	if err := json.Unmarshal(data, &images); err != nil {
+		return nil, err
+	}
+	return &images, nil
+}
+
+// WriteDiffReport writes the report in the given format, text, json or markdown
+func WriteDiffReport(w io.Writer, report *DiffReport, format string) error {
+	switch format {
+	case "json":
+		data, err := JsonIndentMarshal(report)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case "text":
+		return writeDiffText(w, report)
+	case "markdown":
+		return writeDiffMarkdown(w, report)
+	}
+	return fmt.Errorf("report format %s is not supported", format)
+}
+
+func writeDiffText(w io.Writer, report *DiffReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	sections := []struct {
+		title  string
+		images []CollectorImage
+	}{
+		{"Only in " + report.A, report.OnlyInA},
+		{"Only in " + report.B, report.OnlyInB},
+	}
+	for _, section := range sections {
+		fmt.Fprintf(tw, "%s (%d)\n", section.title, len(section.images))
+		for _, image := range section.images {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", image.Namespace, image.Image, image.Digest)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	fmt.Fprintf(tw, "Changed (%d)\n", len(report.Changed))
+	for _, image := range report.Changed {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", image.Namespace, image.Image, image.Digest)
+		for _, change := range image.Changes {
+			fmt.Fprintf(tw, "    %s\t%s\t-> %s\n", change.Field, diffValue(change.Previous), diffValue(change.Current))
+		}
+	}
+
+	return tw.Flush()
+}
+
+func writeDiffMarkdown(w io.Writer, report *DiffReport) error {
+	var md strings.Builder
+
+	fmt.Fprintf(&md, "# Image diff of %s and %s\n", report.A, report.B)
+
+	sections := []struct {
+		title  string
+		images []CollectorImage
+	}{
+		{"Only in " + report.A, report.OnlyInA},
+		{"Only in " + report.B, report.OnlyInB},
+	}
+	for _, section := range sections {
+		fmt.Fprintf(&md, "\n## %s (%d)\n\n", section.title, len(section.images))
+		if len(section.images) == 0 {
+			continue
+		}
+		md.WriteString("| Namespace | Image | Digest |\n|---|---|---|\n")
+		for _, image := range section.images {
+			fmt.Fprintf(&md, "| %s | %s | %s |\n", markdownCell(image.Namespace), markdownCell(image.Image), markdownCell(image.Digest))
+		}
+	}
+
+	fmt.Fprintf(&md, "\n## Changed (%d)\n\n", len(report.Changed))
+	if len(report.Changed) > 0 {
+		fmt.Fprintf(&md, "| Namespace | Image | Field | %s | %s |\n|---|---|---|---|---|\n", markdownCell(report.A), markdownCell(report.B))
+		for _, image := range report.Changed {
+			for _, change := range image.Changes {
+				fmt.Fprintf(&md, "| %s | %s | %s | %s | %s |\n", markdownCell(image.Namespace), markdownCell(image.Image), change.Field,
+					markdownCell(diffValue(change.Previous)), markdownCell(diffValue(change.Current)))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, md.String())
+	return err
+}
+
+// diffValue formats a changed value as json, e.g. "team" or ["a","b"]
+func diffValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func markdownCell(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var diffImagesA = []CollectorImage{
+	{Namespace: "shire", Image: "quay.io/hobbit:1", Registry: "quay.io", Repository: "hobbit", Tag: "1", Digest: "sha256:1", Team: "hobbits"},
+	{Namespace: "shire", Image: "quay.io/pipe:1", Registry: "quay.io", Repository: "pipe", Tag: "1", Digest: "sha256:2", Team: "hobbits"},
+}
+
+var diffImagesB = []CollectorImage{
+	{Namespace: "shire", Image: "quay.io/hobbit:1", Registry: "quay.io", Repository: "hobbit", Tag: "1", Digest: "sha256:3", Team: "hobbits"},
+	{Namespace: "shire", Image: "quay.io/pipe:2", Registry: "quay.io", Repository: "pipe", Tag: "2", Digest: "sha256:2", Team: "gandalf|wizards"},
+}
+
+func TestNewDiffReport(t *testing.T) {
+	byDigest, err := ImageKeyFor(CompareByDigest)
+	assert.NoError(t, err)
+	report, err := NewDiffReport("staging", &diffImagesA, "prod", &diffImagesB, byDigest)
+	assert.NoError(t, err)
+	assert.Equal(t, []CollectorImage{diffImagesA[0]}, report.OnlyInA)
+	assert.Equal(t, []CollectorImage{diffImagesB[0]}, report.OnlyInB)
+	assert.Len(t, report.Changed, 1)
+	assert.Equal(t, []string{"image", "tag", "team"}, changedFields(report.Changed[0]))
+
+	byTag, err := ImageKeyFor(CompareByTag)
+	assert.NoError(t, err)
+	report, err = NewDiffReport("staging", &diffImagesA, "prod", &diffImagesB, byTag)
+	assert.NoError(t, err)
+	assert.Equal(t, []CollectorImage{diffImagesA[1]}, report.OnlyInA)
+	assert.Equal(t, []CollectorImage{diffImagesB[1]}, report.OnlyInB)
+	assert.Len(t, report.Changed, 1)
+	assert.Equal(t, []string{"digest"}, changedFields(report.Changed[0]))
+
+	_, err = ImageKeyFor("name")
+	assert.Error(t, err)
+}
+
+func changedFields(image ChangedImage) []string {
+	var fields []string
+	for _, change := range image.Changes {
+		fields = append(fields, change.Field)
+	}
+	return fields
+}
+
+func TestWriteDiffReport(t *testing.T) {
+	report, err := NewDiffReport("staging", &diffImagesA, "prod", &diffImagesB, KeyByDigest)
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	assert.NoError(t, WriteDiffReport(&out, report, "text"))
+	text := out.String()
+	assert.Contains(t, text, "Only in staging (1)\n")
+	assert.Contains(t, text, "Only in prod (1)\n")
+	assert.Contains(t, text, "Changed (1)\n")
+	assert.Regexp(t, `team\s+"hobbits"\s+-> "gandalf\|wizards"\n`, text)
+
+	out.Reset()
+	assert.NoError(t, WriteDiffReport(&out, report, "markdown"))
+	markdown := out.String()
+	assert.Contains(t, markdown, "# Image diff of staging and prod\n")
+	assert.Contains(t, markdown, "| shire | quay.io/hobbit:1 | sha256:1 |\n")
+	assert.Contains(t, markdown, "| shire | quay.io/pipe:2 | team | \"hobbits\" | \"gandalf\\|wizards\" |\n")
+
+	out.Reset()
+	assert.NoError(t, WriteDiffReport(&out, report, "json"))
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, "staging", decoded["a"])
+	assert.Len(t, decoded["only_in_a"], 1)
+
+	assert.Error(t, WriteDiffReport(&out, report, "html"))
+}
+
+func TestReadInventory(t *testing.T) {
+	aggregated, err := AggregateImages(&diffImagesA)
+	assert.NoError(t, err)
+
+	for _, data := range [][]byte{
+		must(JsonIndentMarshal(diffImagesA)),
+		must(JsonIndentMarshal(aggregated)),
+	} {
+		images, err := ReadInventory(data)
+		assert.NoError(t, err)
+		assert.Equal(t, diffImagesA, *images)
+	}
+
+	_, err = ReadInventory([]byte(`{"added": []}`))
+	assert.Error(t, err)
+}
+
+func must(data []byte, err error) []byte {
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,235 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats of the images, see NewEncoder
+const (
+	OutputFormatJSON   = "json"
+	OutputFormatNDJSON = "ndjson"
+	OutputFormatCSV    = "csv"
+	OutputFormatYAML   = "yaml"
+)
+
+// DefaultCSVColumns are the columns of the csv output if none are configured
+var DefaultCSVColumns = []string{"namespace", "image", "image_id", "workload_kind", "workload_name", "container_name", "team", "product", "environment", "skip"}
+
+// Encoder marshals the images passed to Store, flat or aggregated
+type Encoder struct {
+	// Extension of the output file, e.g. '.json'
+	Extension string
+	Marshal   JsonMarshal
+	// Envelope is true if the images can be wrapped in an Envelope, see EnvelopeMarshal
+	Envelope bool
+	// NewItemEncoder is nil if the format only encodes whole lists, e.g. a json array
+	NewItemEncoder func(w io.Writer) (ItemEncoder, error)
+}
+
+// ItemEncoder writes images one by one, e.g. while they are collected. Close writes what is buffered.
+type ItemEncoder interface {
+	Encode(item any) error
+	Close() error
+}
+
+// EncoderOptions configure the encoders, options of other formats are ignored
+type EncoderOptions struct {
+	// CSVColumns are the json field names of the images, DefaultCSVColumns if empty
+	CSVColumns []string
+}
+
+var encoders = map[string]func(options *EncoderOptions) (Encoder, error){}
+
+// RegisterEncoder adds an output format
+func RegisterEncoder(format string, newEncoder func(options *EncoderOptions) (Encoder, error)) {
+	encoders[format] = newEncoder
+}
+
+// OutputFormats returns the names of all registered output formats, sorted
+func OutputFormats() []string {
+	var formats []string
+	for format := range encoders {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// NewEncoder returns the encoder of the output format
+func NewEncoder(format string, options *EncoderOptions) (Encoder, error) {
+	newEncoder, ok := encoders[format]
+	if !ok {
+		return Encoder{}, fmt.Errorf("output format %s is not supported, supported are %s", format, strings.Join(OutputFormats(), ", "))
+	}
+	return newEncoder(options)
+}
+
+func init() {
+	RegisterEncoder(OutputFormatJSON, func(options *EncoderOptions) (Encoder, error) {
+		return Encoder{Extension: ".json", Marshal: JsonIndentMarshal, Envelope: true}, nil
+	})
+	RegisterEncoder(OutputFormatNDJSON, func(options *EncoderOptions) (Encoder, error) {
+		return Encoder{Extension: ".ndjson", Marshal: ndjsonMarshal, NewItemEncoder: newNDJSONItemEncoder}, nil
+	})
+	RegisterEncoder(OutputFormatYAML, func(options *EncoderOptions) (Encoder, error) {
+		return Encoder{Extension: ".yaml", Marshal: yaml.Marshal, Envelope: true}, nil
+	})
+	RegisterEncoder(OutputFormatCSV, newCSVEncoder)
+}
+
+// forEachItem calls fn with every item of the slice v points to, without copying the slice
+func forEachItem(v any, fn func(item any) error) error {
+	items := reflect.Indirect(reflect.ValueOf(v))
+	if items.Kind() != reflect.Slice {
+		return fmt.Errorf("only lists can be encoded, got %T", v)
+	}
+	for i := 0; i < items.Len(); i++ {
+		if err := fn(items.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// itemsMarshal encodes every item of the list with an item encoder
+func itemsMarshal(v any, newItemEncoder func(w io.Writer) (ItemEncoder, error)) ([]byte, error) {
+	var data bytes.Buffer
+	encoder, err := newItemEncoder(&data)
+	if err != nil {
+		return nil, err
+	}
+	if err = forEachItem(v, encoder.Encode); err != nil {
+		return nil, err
+	}
+	if err = encoder.Close(); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+// ndjsonMarshal writes every image as json on a line of its own
+func ndjsonMarshal(v any) ([]byte, error) {
+	return itemsMarshal(v, newNDJSONItemEncoder)
+}
+
+type ndjsonItemEncoder struct {
+	*json.Encoder
+}
+
+func newNDJSONItemEncoder(w io.Writer) (ItemEncoder, error) {
+	return ndjsonItemEncoder{json.NewEncoder(w)}, nil
+}
+
+func (e ndjsonItemEncoder) Close() error {
+	return nil
+}
+
+func newCSVEncoder(options *EncoderOptions) (Encoder, error) {
+	columns := DefaultCSVColumns
+	if options != nil && len(options.CSVColumns) > 0 {
+		columns = options.CSVColumns
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(AggregatedImage{}))
+	for _, column := range columns {
+		if !known[column] {
+			return Encoder{}, fmt.Errorf("csv column %s is not a field of the images", column)
+		}
+	}
+
+	newItemEncoder := func(w io.Writer) (ItemEncoder, error) { return newCSVItemEncoder(w, columns) }
+	return Encoder{
+		Extension:      ".csv",
+		Marshal:        func(v any) ([]byte, error) { return itemsMarshal(v, newItemEncoder) },
+		NewItemEncoder: newItemEncoder,
+	}, nil
+}
+
+// csvItemEncoder writes a header and a row per image with the json fields of the columns.
+// Lists of strings are joined by commas, other lists and objects are written as json.
+type csvItemEncoder struct {
+	w       *csv.Writer
+	columns []string
+}
+
+func newCSVItemEncoder(w io.Writer, columns []string) (ItemEncoder, error) {
+	e := &csvItemEncoder{w: csv.NewWriter(w), columns: columns}
+	if err := e.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *csvItemEncoder) Encode(item any) error {
+	image, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(image, &fields); err != nil {
+		return err
+	}
+
+	row := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		if row[i], err = csvValue(fields[column]); err != nil {
+			return err
+		}
+	}
+	return e.w.Write(row)
+}
+
+func (e *csvItemEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func csvValue(field json.RawMessage) (string, error) {
+	if len(field) == 0 || string(field) == "null" {
+		return "", nil
+	}
+
+	var s string
+	if json.Unmarshal(field, &s) == nil {
+		return s, nil
+	}
+	var list []string
+	if json.Unmarshal(field, &list) == nil {
+		return strings.Join(list, ","), nil
+	}
+
+	// Numbers, bools, objects and other lists
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, field); err != nil {
+		return "", err
+	}
+	return compact.String(), nil
+}
+
+// jsonFieldNames returns the json names of the fields of the struct, including the ones of embedded structs
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for name := range jsonFieldNames(field.Type) {
+				names[name] = true
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.IsExported() && name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
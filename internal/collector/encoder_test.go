@@ -0,0 +1,127 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+var encoderImages = []CollectorImage{
+	{Namespace: "shire", Image: "quay.io/hobbit:1", Team: "hobbits", EngagementTags: []string{"a", "b"}, Skip: true, ScanLifetimeMaxDays: 120},
+	{Namespace: "mordor", Image: "quay.io/orc:2", Team: "orcs, uruks", EngagementTags: []string{}},
+}
+
+func TestOutputFormats(t *testing.T) {
+	assert.Subset(t, OutputFormats(), []string{OutputFormatCSV, OutputFormatJSON, OutputFormatNDJSON, OutputFormatYAML})
+
+	_, err := NewEncoder("xml", nil)
+	assert.Error(t, err)
+}
+
+func TestEncoderExtensions(t *testing.T) {
+	for format, extension := range map[string]string{
+		OutputFormatJSON:   ".json",
+		OutputFormatNDJSON: ".ndjson",
+		OutputFormatCSV:    ".csv",
+		OutputFormatYAML:   ".yaml",
+	} {
+		encoder, err := NewEncoder(format, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, extension, encoder.Extension, format)
+	}
+}
+
+func TestNDJSONEncoder(t *testing.T) {
+	encoder, err := NewEncoder(OutputFormatNDJSON, nil)
+	assert.NoError(t, err)
+
+	var data bytes.Buffer
+	assert.NoError(t, Store(&encoderImages, &data, encoder.Marshal))
+
+	lines := strings.Split(strings.TrimSuffix(data.String(), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	for i, line := range lines {
+		var image CollectorImage
+		assert.NoError(t, json.Unmarshal([]byte(line), &image))
+		assert.Equal(t, encoderImages[i].Image, image.Image)
+	}
+
+	aggregated, err := AggregateImages(&encoderImages)
+	assert.NoError(t, err)
+	data.Reset()
+	assert.NoError(t, Store(aggregated, &data, encoder.Marshal))
+	assert.Equal(t, 2, strings.Count(data.String(), "\n"))
+	assert.Contains(t, data.String(), `"locations":`)
+
+	_, err = encoder.Marshal(&struct{}{})
+	assert.Error(t, err, "Only lists can be encoded")
+}
+
+func TestYAMLEncoder(t *testing.T) {
+	encoder, err := NewEncoder(OutputFormatYAML, nil)
+	assert.NoError(t, err)
+
+	var data bytes.Buffer
+	assert.NoError(t, Store(&encoderImages, &data, encoder.Marshal))
+	assert.Contains(t, data.String(), "  namespace: shire\n")
+
+	var images []CollectorImage
+	assert.NoError(t, yaml.Unmarshal(data.Bytes(), &images))
+	assert.Equal(t, encoderImages[0].Team, images[0].Team)
+}
+
+func TestCSVEncoder(t *testing.T) {
+	encoder, err := NewEncoder(OutputFormatCSV, &EncoderOptions{CSVColumns: []string{"namespace", "team", "engagement_tags", "skip", "scan_lifetime_max_days", "cluster"}})
+	assert.NoError(t, err)
+
+	var data bytes.Buffer
+	assert.NoError(t, Store(&encoderImages, &data, encoder.Marshal))
+	assert.Equal(t, "namespace,team,engagement_tags,skip,scan_lifetime_max_days,cluster\n"+
+		"shire,hobbits,\"a,b\",true,120,\n"+
+		"mordor,\"orcs, uruks\",,false,0,\n", data.String())
+
+	// Aggregated images have locations
+	encoder, err = NewEncoder(OutputFormatCSV, &EncoderOptions{CSVColumns: []string{"image", "replicas", "locations"}})
+	assert.NoError(t, err)
+	aggregated := []AggregatedImage{{CollectorImage: encoderImages[0], Replicas: 1, Locations: []ImageLocation{{Pod: "frodo"}}}}
+	data.Reset()
+	assert.NoError(t, Store(&aggregated, &data, encoder.Marshal))
+	assert.Contains(t, data.String(), `quay.io/hobbit:1,1,"[{""pod"":""frodo""`)
+
+	encoder, err = NewEncoder(OutputFormatCSV, nil)
+	assert.NoError(t, err)
+	data.Reset()
+	assert.NoError(t, Store(&encoderImages, &data, encoder.Marshal))
+	assert.True(t, strings.HasPrefix(data.String(), strings.Join(DefaultCSVColumns, ",")+"\n"))
+
+	_, err = NewEncoder(OutputFormatCSV, &EncoderOptions{CSVColumns: []string{"namespace", "colour"}})
+	assert.Error(t, err)
+}
+
+func TestItemEncoder(t *testing.T) {
+	for _, format := range []string{OutputFormatNDJSON, OutputFormatCSV} {
+		encoder, err := NewEncoder(format, nil)
+		assert.NoError(t, err)
+		expected, err := encoder.Marshal(&encoderImages)
+		assert.NoError(t, err)
+
+		var data bytes.Buffer
+		itemEncoder, err := encoder.NewItemEncoder(&data)
+		assert.NoError(t, err)
+		for i := range encoderImages {
+			assert.NoError(t, itemEncoder.Encode(&encoderImages[i]))
+		}
+		assert.NoError(t, itemEncoder.Close())
+		assert.Equal(t, string(expected), data.String(), "Expected the images encoded one by one like the list of %s", format)
+	}
+
+	for _, format := range []string{OutputFormatJSON, OutputFormatYAML} {
+		encoder, err := NewEncoder(format, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, encoder.NewItemEncoder, "Expected %s to only encode whole lists", format)
+	}
+}
@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SchemaVersion of the stored images, increased on incompatible changes of CollectorImage or the Envelope
+const SchemaVersion = "1"
+
+// EnvelopeMetadata describes which collector, environment and cluster produced the images
+type EnvelopeMetadata struct {
+	SchemaVersion    string `json:"schema_version"`
+	CollectorVersion string `json:"collector_version"`
+	Environment      string `json:"environment"`
+	// Name of the cluster, only set if several clusters are collected in one run
+	Cluster     string    `json:"cluster,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// KubeServerVersion is empty for offline input and combined output of several clusters
+	KubeServerVersion string `json:"kube_server_version,omitempty"`
+}
+
+// Envelope wraps the images passed to Store, flat or aggregated, with their metadata
+type Envelope struct {
+	EnvelopeMetadata
+	Images any `json:"images"`
+}
+
+// EnvelopeMarshal wraps the images in an Envelope before marshaling them
+func EnvelopeMarshal(metadata *EnvelopeMetadata, marshal JsonMarshal) JsonMarshal {
+	return func(v any) ([]byte, error) {
+		return marshal(&Envelope{EnvelopeMetadata: *metadata, Images: v})
+	}
+}
+
+// readEnvelope parses the images of a json envelope of the current SchemaVersion, ok is false if the data is no object
+func readEnvelope(data []byte) (images *[]CollectorImage, ok bool, err error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return nil, false, nil
+	}
+
+	var envelope struct {
+		EnvelopeMetadata
+		Images []CollectorImage `json:"images"`
+	}
+	if err = json.Unmarshal(data, &envelope); err != nil {
+		return nil, true, err
+	}
+	switch envelope.SchemaVersion {
+	case SchemaVersion:
+		return &envelope.Images, true, nil
+	case "":
+		return nil, true, errors.New("object without schema_version is not an envelope of images")
+	}
+	return nil, true, fmt.Errorf("schema version %s is not supported, supported is %s", envelope.SchemaVersion, SchemaVersion)
+}
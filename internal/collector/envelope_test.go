@@ -0,0 +1,63 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+var envelopeMetadata = EnvelopeMetadata{
+	SchemaVersion:     SchemaVersion,
+	CollectorVersion:  "1.2.3",
+	Environment:       "middle-earth",
+	GeneratedAt:       time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	KubeServerVersion: "v1.29.2",
+}
+
+func TestEnvelopeMarshal(t *testing.T) {
+	var data bytes.Buffer
+	assert.NoError(t, Store(&encoderImages, &data, EnvelopeMarshal(&envelopeMetadata, JsonIndentMarshal)))
+
+	var envelope map[string]any
+	assert.NoError(t, json.Unmarshal(data.Bytes(), &envelope))
+	assert.Equal(t, "1", envelope["schema_version"])
+	assert.Equal(t, "1.2.3", envelope["collector_version"])
+	assert.Equal(t, "middle-earth", envelope["environment"])
+	assert.Equal(t, "2024-03-01T12:00:00Z", envelope["generated_at"])
+	assert.Equal(t, "v1.29.2", envelope["kube_server_version"])
+	assert.NotContains(t, envelope, "cluster")
+	assert.Len(t, envelope["images"], 2)
+
+	images, err := ReadInventory(data.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, encoderImages, *images)
+
+	_, err = ReadInventory([]byte(`{"schema_version": "2", "images": []}`))
+	assert.ErrorContains(t, err, "schema version 2 is not supported")
+}
+
+func TestEnvelopeMarshalYAML(t *testing.T) {
+	encoder, err := NewEncoder(OutputFormatYAML, nil)
+	assert.NoError(t, err)
+	assert.True(t, encoder.Envelope)
+
+	var data bytes.Buffer
+	assert.NoError(t, Store(&encoderImages, &data, EnvelopeMarshal(&envelopeMetadata, encoder.Marshal)))
+	assert.Contains(t, data.String(), "schema_version: \"1\"\n")
+
+	var envelope Envelope
+	assert.NoError(t, yaml.Unmarshal(data.Bytes(), &envelope))
+	assert.Equal(t, envelopeMetadata, envelope.EnvelopeMetadata)
+}
+
+func TestEnvelopeFormats(t *testing.T) {
+	for _, format := range []string{OutputFormatNDJSON, OutputFormatCSV, OutputFormatCycloneDXJSON, OutputFormatSPDXJSON} {
+		encoder, err := NewEncoder(format, nil)
+		assert.NoError(t, err)
+		assert.False(t, encoder.Envelope, format)
+	}
+}
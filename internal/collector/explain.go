@@ -0,0 +1,79 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// Explain writes a human-readable breakdown of the effective values of the images, where each value came from,
+// the label/annotation keys looked up, malformed values which fell back to defaults and the skip decision.
+// The images must be converted with provenance enabled.
+func Explain(w io.Writer, images *[]CollectorImage) error {
+	if len(*images) == 0 {
+		_, err := fmt.Fprintln(w, "No images found")
+		return err
+	}
+
+	for _, image := range *images {
+		if err := explainImage(w, &image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func explainImage(w io.Writer, image *CollectorImage) error {
+	data, err := json.Marshal(image)
+	if err != nil {
+		return err
+	}
+	var values map[string]any
+	if err = json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Image %s (namespace %s, pod %s, container %s [%s], workload %s/%s)\n",
+		image.Image, image.Namespace, image.Pod, image.ContainerName, image.ContainerRole, image.WorkloadKind, image.WorkloadName)
+
+	skip := image.Provenance["skip"]
+	if image.Skip {
+		fmt.Fprintf(w, "  Skipped by %s %s\n", skip.Source, skip.Key)
+	} else {
+		fmt.Fprintln(w, "  Not skipped")
+	}
+
+	fields := make([]string, 0, len(image.Provenance))
+	for field := range image.Provenance {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, field := range fields {
+		p := image.Provenance[field]
+		value, _ := json.Marshal(values[field])
+
+		origin := p.Source
+		if p.Key != "" {
+			origin += " " + p.Key
+		}
+		if p.Lookup != "" && p.Lookup != p.Key {
+			origin += " (looked up " + p.Lookup + ")"
+		}
+		fmt.Fprintf(tw, "  %s\t= %s\t<- %s\n", field, value, origin)
+
+		// In the last column, which is not padded, so the note doesn't widen the table
+		if p.Ignored != nil {
+			fmt.Fprintf(tw, "  \t\t! ignored malformed value %q of %s %s: %s\n", p.Ignored.Value, p.Ignored.Source, p.Ignored.Key, p.Ignored.Error)
+		}
+	}
+	if err = tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w)
+	return err
+}
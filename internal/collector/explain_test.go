@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	annotationNames := AnnotationNames{
+		Base:       "sda.se/",
+		Scans:      "scans.sda.se/",
+		Contact:    "contact.sda.se/",
+		DefectDojo: "dd.sda.se/",
+	}
+	k8Images := []kubeclient.Image{{
+		Image:         "quay.io/name:tag",
+		NamespaceName: "shire-pr-1",
+		PodName:       "pod-1",
+		ContainerName: "app",
+		ContainerRole: "app",
+		WorkloadKind:  "Deployment",
+		WorkloadName:  "app",
+		Annotations: map[string]string{
+			"contact.sda.se/team":                   "security-journey",
+			"scans.sda.se/scan-lifetime-max-days":   "90d",
+			"scans.sda.se/negated_namespace_filter": "-pr-",
+		},
+	}}
+
+	images, err := ConvertImages(&k8Images, &CollectorImage{ScanLifetimeMaxDays: 120}, &annotationNames, &RunConfig{Provenance: true})
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	assert.NoError(t, Explain(&out, images))
+
+	result := out.String()
+	assert.Contains(t, result, "Image quay.io/name:tag (namespace shire-pr-1, pod pod-1, container app [app], workload Deployment/app)")
+	assert.Contains(t, result, "Skipped by negated-namespace-filter -pr-")
+	assert.Regexp(t, `team\s+= "security-journey"\s+<- pod-annotation contact.sda.se/team\n`, result)
+	assert.Regexp(t, `scan_lifetime_max_days\s+= 120\s+<- default \(looked up scans.sda.se/scan-lifetime-max-days\)\n`, result)
+	assert.Contains(t, result, `! ignored malformed value "90d" of pod-annotation scans.sda.se/scan-lifetime-max-days`)
+	assert.NotRegexp(t, `= "security-journey" {20,}<-`, result, "Expected the ignored value not to widen the table")
+
+	out.Reset()
+	assert.NoError(t, Explain(&out, &[]CollectorImage{}))
+	assert.Equal(t, "No images found\n", out.String())
+}
@@ -0,0 +1,136 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Syntax of the image filters
+const (
+	FilterSyntaxRegex = "regex"
+	FilterSyntaxGlob  = "glob"
+)
+
+// ImageFilter is a compiled image filter pattern
+type ImageFilter struct {
+	Pattern string
+	regexp  *regexp.Regexp
+}
+
+// MatchString reports whether the image matches the filter
+func (f ImageFilter) MatchString(image string) bool {
+	return f.regexp.MatchString(image)
+}
+
+// CompileImageFilters compiles the patterns, globs and anchored regexes have to match the whole image
+func CompileImageFilters(patterns []string, syntax string, anchored bool) ([]ImageFilter, error) {
+	filters := make([]ImageFilter, 0, len(patterns))
+	for _, pattern := range patterns {
+		var expr string
+		switch syntax {
+		case FilterSyntaxRegex, "":
+			expr = pattern
+			if anchored {
+				expr = "^(?:" + pattern + ")$"
+			}
+		case FilterSyntaxGlob:
+			expr = globToRegex(pattern)
+		default:
+			return nil, fmt.Errorf("image filter syntax %s is not supported", syntax)
+		}
+
+		compiled, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image filter %q: %w", pattern, err)
+		}
+		filters = append(filters, ImageFilter{Pattern: pattern, regexp: compiled})
+	}
+	return filters, nil
+}
+
+// globToRegex converts a glob, where * matches any sequence of characters (including /), ? a single character
+// and [...] a character class, into an anchored regex
+func globToRegex(glob string) string {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return expr.String()
+}
+
+// maxCachedNamespaceFilters bounds the namespace filters cached, as they are read from the cluster
+const maxCachedNamespaceFilters = 1024
+
+// regexpCache compiles every pattern only once, invalid patterns are reported once.
+// The cache is cleared once it holds maxCachedNamespaceFilters patterns, e.g. in a long running watch.
+type regexpCache struct {
+	mu      sync.Mutex
+	regexps map[string]*regexp.Regexp
+	errs    map[string]error
+}
+
+// namespaceFilters caches the namespace filters of the labels, annotations and defaults
+var namespaceFilters = &regexpCache{regexps: map[string]*regexp.Regexp{}, errs: map[string]error{}}
+
+func (c *regexpCache) get(pattern string) (*regexp.Regexp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if compiled, ok := c.regexps[pattern]; ok {
+		return compiled, nil
+	}
+	if err, ok := c.errs[pattern]; ok {
+		return nil, err
+	}
+
+	if len(c.regexps)+len(c.errs) >= maxCachedNamespaceFilters {
+		clear(c.regexps)
+		clear(c.errs)
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		log.Warn().Err(err).Str("filter", pattern).Msg("Invalid namespace filter, the filter is ignored")
+		c.errs[pattern] = err
+		return nil, err
+	}
+	c.regexps[pattern] = compiled
+	return compiled, nil
+}
+
+// matchNamespaceFilter reports whether the namespace matches the filter, empty and invalid filters never match
+func matchNamespaceFilter(filter, namespace string) bool {
+	if filter == "" {
+		return false
+	}
+	compiled, err := namespaceFilters.get(filter)
+	if err != nil {
+		return false
+	}
+	return compiled.MatchString(namespace)
+}
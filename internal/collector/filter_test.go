@@ -0,0 +1,87 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileImageFilters(t *testing.T) {
+	image := "quay.io/sdase/image-metadata-collector:1.2.3"
+
+	testCases := []struct {
+		name          string
+		pattern       string
+		syntax        string
+		anchored      bool
+		expectedMatch bool
+		expectedError bool
+	}{
+		{name: "RegexPartialMatch", pattern: "sdase/", syntax: FilterSyntaxRegex, expectedMatch: true},
+		{name: "DefaultSyntaxIsRegex", pattern: "sdase/", syntax: "", expectedMatch: true},
+		{name: "AnchoredRegexPartialMatch", pattern: "sdase/", syntax: FilterSyntaxRegex, anchored: true, expectedMatch: false},
+		{name: "AnchoredRegexFullMatch", pattern: `quay\.io/.*|docker\.io/.*`, syntax: FilterSyntaxRegex, anchored: true, expectedMatch: true},
+		{name: "GlobMatch", pattern: "quay.io/*:1.?.3", syntax: FilterSyntaxGlob, expectedMatch: true},
+		{name: "GlobIsAnchored", pattern: "sdase/*", syntax: FilterSyntaxGlob, expectedMatch: false},
+		{name: "GlobDotIsLiteral", pattern: "quay?io/*", syntax: FilterSyntaxGlob, expectedMatch: true},
+		{name: "GlobDotDoesNotMatchAnyCharacter", pattern: "quay.i./*", syntax: FilterSyntaxGlob, expectedMatch: false},
+		{name: "GlobCharacterClass", pattern: "*:[0-9].*", syntax: FilterSyntaxGlob, expectedMatch: true},
+		{name: "GlobNegatedCharacterClass", pattern: "*:[!0-9].*", syntax: FilterSyntaxGlob, expectedMatch: false},
+		{name: "InvalidRegex", pattern: "quay.io/(", syntax: FilterSyntaxRegex, expectedError: true},
+		{name: "UnsupportedSyntax", pattern: "quay.io", syntax: "wildcard", expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filters, err := CompileImageFilters([]string{tc.pattern}, tc.syntax, tc.anchored)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.pattern, filters[0].Pattern)
+			assert.Equal(t, tc.expectedMatch, filters[0].MatchString(image))
+		})
+	}
+}
+
+func TestCompileInvalidImageFilter(t *testing.T) {
+	runConfig := RunConfig{ImageFilter: []string{"mongo", "[a-"}}
+	assert.ErrorContains(t, runConfig.CompileImageFilters(), `invalid image filter "[a-"`)
+}
+
+func TestConvertImagesUncompiledImageFilter(t *testing.T) {
+	k8Images := []kubeclient.Image{{Image: "mongo:7"}}
+	runConfig := RunConfig{ImageFilter: []string{"mongo"}}
+	images, err := ConvertImages(&k8Images, &CollectorImage{}, &AnnotationNames{}, &runConfig)
+	assert.NoError(t, err)
+	assert.True(t, (*images)[0].Skip, "Expected the image filters to be compiled on the first conversion")
+
+	runConfig = RunConfig{ImageFilter: []string{"[a-"}}
+	_, err = ConvertImages(&k8Images, &CollectorImage{}, &AnnotationNames{}, &runConfig)
+	assert.ErrorContains(t, err, `invalid image filter "[a-"`)
+}
+
+func TestMatchNamespaceFilter(t *testing.T) {
+	assert.False(t, matchNamespaceFilter("", "shire"))
+	assert.True(t, matchNamespaceFilter("^sh", "shire"))
+	assert.False(t, matchNamespaceFilter("^mor", "shire"))
+
+	// Invalid filters never match and are cached as invalid
+	assert.False(t, matchNamespaceFilter("shire(", "shire("))
+	assert.Contains(t, namespaceFilters.errs, "shire(")
+	assert.Contains(t, namespaceFilters.regexps, "^sh")
+}
+
+func TestRegexpCacheBound(t *testing.T) {
+	cache := &regexpCache{regexps: map[string]*regexp.Regexp{}, errs: map[string]error{}}
+	for i := 0; i < 2*maxCachedNamespaceFilters; i++ {
+		_, err := cache.get(fmt.Sprintf("^team-%d$", i))
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(cache.regexps), maxCachedNamespaceFilters)
+	}
+}
@@ -1,65 +1,82 @@
 package collector
 
 import (
+	"bytes"
 	"encoding/json"
 	"strconv"
 	"strings"
 )
 
-// GetOrDefaultBool returns the value of the given name from the map m or the default value if it doesn't exist.
-func GetOrDefaultBool(m map[string]string, name string, default_ bool) bool {
-	var value bool
-	var err error
-
+// LookupBool returns the parsed value of the given name from the map m and whether it exists.
+// The error is set if the value exists but is not a bool.
+func LookupBool(m map[string]string, name string) (bool, bool, error) {
 	value_, success := m[name]
-
-	if success {
-		value, err = strconv.ParseBool(value_)
+	if !success {
+		return false, false, nil
 	}
 
+	value, err := strconv.ParseBool(value_)
+	return value, true, err
+}
+
+// GetOrDefaultBool returns the value of the given name from the map m or the default value if it doesn't exist.
+func GetOrDefaultBool(m map[string]string, name string, default_ bool) bool {
+	value, success, err := LookupBool(m, name)
 	if !success || err != nil {
 		value = default_
 	}
 	return value
 }
 
+// LookupString returns the value of the given name from the map m and whether it exists and is not empty.
+func LookupString(m map[string]string, name string) (string, bool) {
+	value, success := m[name]
+	return value, success && len(value) != 0
+}
+
 // GetOrDefaultString returns the value of the given name from the map m or the default value if it doesn't exist.
 func GetOrDefaultString(m map[string]string, name, default_ string) string {
-	value, success := m[name]
+	value, success := LookupString(m, name)
 	if !success {
 		value = default_
 	}
+	return value
+}
 
-	if len(value) == 0 {
-		value = default_
+// LookupInt64 returns the parsed value of the given name from the map m and whether it exists.
+// The error is set if the value exists but is not an int64.
+func LookupInt64(m map[string]string, name string) (int64, bool, error) {
+	value_, success := m[name]
+	if !success {
+		return 0, false, nil
 	}
 
-	return value
+	value, err := strconv.ParseInt(value_, 10, 64)
+	return value, true, err
 }
 
 // GetOrDefaultInt64 the value of the given name from the map m or the default value if it doesn't exist.
 func GetOrDefaultInt64(m map[string]string, name string, default_ int64) int64 {
-	var value int64
-	var err error
-
-	value_, success := m[name]
-	if success {
-		value, err = strconv.ParseInt(value_, 10, 64)
-	}
-
+	value, success, err := LookupInt64(m, name)
 	if !success || err != nil {
 		value = default_
 	}
 	return value
 }
 
+// LookupStringSlice returns the comma separated values of the given name from the map m and whether it exists.
+func LookupStringSlice(m map[string]string, name string) ([]string, bool) {
+	value_, success := m[name]
+	if !success {
+		return nil, false
+	}
+	return strings.Split(value_, ","), true
+}
+
 // GetOrDefaultStringSlice the value of the given name from the map m or the default value if it doesn't exist.
 func GetOrDefaultStringSlice(m map[string]string, name string, default_ []string) []string {
-	var value []string
-	value_, success := m[name]
-	if success {
-		value = strings.Split(value_, ",")
-	} else {
+	value, success := LookupStringSlice(m, name)
+	if !success {
 		value = default_
 	}
 	return value
@@ -70,3 +87,15 @@ type JsonMarshal func(any) ([]byte, error)
 func JsonIndentMarshal(v any) ([]byte, error) {
 	return json.MarshalIndent(v, "", "\t")
 }
+
+// jsonIndentMarshalUnescaped is JsonIndentMarshal without escaping &, < and >, e.g. of URLs in documents of other tools
+func jsonIndentMarshalUnescaped(v any) ([]byte, error) {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "\t")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(data.Bytes(), []byte("\n")), nil
+}
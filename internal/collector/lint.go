@@ -0,0 +1,245 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
+)
+
+// Types of the values of known annotations
+const (
+	valueString      = "string"
+	valueStringSlice = "string-slice"
+	valueBool        = "bool"
+	valueInt64       = "int64"
+	valueRegex       = "regex"
+)
+
+// Problems reported by Lint
+const (
+	ProblemUnknownKey   = "unknown-key"
+	ProblemInvalidBool  = "invalid-bool"
+	ProblemInvalidInt   = "invalid-int"
+	ProblemInvalidRegex = "invalid-regex"
+)
+
+// knownAnnotations returns the type of every label/annotation key read by convertK8ImageToCollectorImage
+func knownAnnotations(annotationNames *AnnotationNames) map[string]string {
+	known := map[string]string{
+		annotationNames.Base + "environment":    valueString,
+		annotationNames.Base + "product":        valueString,
+		annotationNames.Base + "description":    valueString,
+		annotationNames.Base + "container-type": valueString,
+
+		annotationNames.Scans + "skip":                     valueBool,
+		annotationNames.Scans + "namespace-filter":         valueRegex,
+		annotationNames.Scans + "negated_namespace_filter": valueRegex,
+		annotationNames.Scans + "scan-lifetime-max-days":   valueInt64,
+
+		annotationNames.Contact + "team":  valueString,
+		annotationNames.Contact + "slack": valueString,
+		annotationNames.Contact + "email": valueString,
+
+		annotationNames.DefectDojo + "engagement-tags": valueStringSlice,
+	}
+
+	for _, scan := range []string{
+		"is-scan-baseimage-lifetime",
+		"is-scan-dependency-check",
+		"is-scan-dependency-track",
+		"is-scan-distroless",
+		"is-scan-lifetime",
+		"is-scan-malware",
+		"is-scan-new-version",
+		"is-scan-runasroot",
+		"is-scan-potentially-running-as-root",
+		"is-scan-run-as-privileged",
+		"is-scan-potentially-running-as-privileged",
+	} {
+		known[annotationNames.Scans+scan] = valueBool
+	}
+
+	return known
+}
+
+// Finding is a malformed or unknown label/annotation
+type Finding struct {
+	Object  string `json:"object"`
+	Source  string `json:"source"`
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Problem string `json:"problem"`
+	Message string `json:"message"`
+}
+
+// LintTags checks all keys under the configured annotation name prefixes for unknown names,
+// values which are not parsable as bool or int and invalid regexes
+func LintTags(object, source string, tags map[string]string, annotationNames *AnnotationNames) []Finding {
+	known := knownAnnotations(annotationNames)
+	prefixes := []string{annotationNames.Base, annotationNames.Scans, annotationNames.Contact, annotationNames.DefectDojo}
+
+	var findings []Finding
+	for key, value := range tags {
+		if !hasAnyPrefix(key, prefixes) {
+			continue
+		}
+
+		finding := Finding{Object: object, Source: source, Key: key, Value: value}
+
+		valueType, ok := known[key]
+		if !ok {
+			finding.Problem = ProblemUnknownKey
+			finding.Message = "unknown key"
+			if suggestion := closestKey(key, known); suggestion != "" {
+				finding.Message += ", did you mean " + suggestion + "?"
+			}
+			findings = append(findings, finding)
+			continue
+		}
+
+		var err error
+		switch valueType {
+		case valueBool:
+			_, err = strconv.ParseBool(value)
+			finding.Problem = ProblemInvalidBool
+		case valueInt64:
+			_, err = strconv.ParseInt(value, 10, 64)
+			finding.Problem = ProblemInvalidInt
+		case valueRegex:
+			_, err = regexp.Compile(value)
+			finding.Problem = ProblemInvalidRegex
+		}
+		if err != nil {
+			finding.Message = err.Error()
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings
+}
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// closestKey returns the known key with the smallest edit distance to key, if it is likely a typo
+func closestKey(key string, known map[string]string) string {
+	closest := ""
+	closestDistance := 4
+	for candidate := range known {
+		distance := editDistance(key, candidate)
+		if distance < closestDistance || (distance == closestDistance && candidate < closest) {
+			closest = candidate
+			closestDistance = distance
+		}
+	}
+	return closest
+}
+
+// editDistance is the Levenshtein distance of a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// Lint checks the labels and annotations of all namespaces and of all pods and workloads of the images
+func Lint(namespaces *[]kubeclient.Namespace, k8Images *[]kubeclient.Image, annotationNames *AnnotationNames) []Finding {
+	findings := []Finding{}
+
+	for _, namespace := range *namespaces {
+		object := "namespace/" + namespace.Name
+		findings = append(findings, LintTags(object, SourceNamespaceAnnotation, namespace.Annotations, annotationNames)...)
+		findings = append(findings, LintTags(object, SourceNamespaceLabel, namespace.Labels, annotationNames)...)
+	}
+
+	objects := map[string]bool{}
+	for _, k8Image := range *k8Images {
+		if k8Image.Error != "" {
+			continue
+		}
+
+		// Declared images have the labels and annotations of the pod template instead of a pod
+		object := "pod/" + k8Image.NamespaceName + "/" + k8Image.PodName
+		if k8Image.PodName == "" {
+			object = strings.ToLower(k8Image.WorkloadKind) + "/" + k8Image.NamespaceName + "/" + k8Image.WorkloadName + "/template"
+		}
+		if !objects[object] {
+			objects[object] = true
+			findings = append(findings, LintTags(object, SourcePodAnnotation, k8Image.Annotations, annotationNames)...)
+			findings = append(findings, LintTags(object, SourcePodLabel, k8Image.Labels, annotationNames)...)
+		}
+
+		object = strings.ToLower(k8Image.WorkloadKind) + "/" + k8Image.NamespaceName + "/" + k8Image.WorkloadName
+		if k8Image.WorkloadKind != "Pod" && !objects[object] {
+			objects[object] = true
+			findings = append(findings, LintTags(object, SourceWorkloadAnnotation, k8Image.WorkloadAnnotations, annotationNames)...)
+			findings = append(findings, LintTags(object, SourceWorkloadLabel, k8Image.WorkloadLabels, annotationNames)...)
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Object != findings[j].Object {
+			return findings[i].Object < findings[j].Object
+		}
+		if findings[i].Source != findings[j].Source {
+			return findings[i].Source < findings[j].Source
+		}
+		return findings[i].Key < findings[j].Key
+	})
+
+	return findings
+}
+
+// WriteLintReport writes the findings in the given format, text or json
+func WriteLintReport(w io.Writer, findings []Finding, format string) error {
+	switch format {
+	case "json":
+		data, err := JsonIndentMarshal(findings)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case "text":
+		if len(findings) == 0 {
+			_, err := fmt.Fprintln(w, "No findings")
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "OBJECT\tSOURCE\tKEY\tVALUE\tPROBLEM\tMESSAGE")
+		for _, f := range findings {
+			value, _ := json.Marshal(f.Value)
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", f.Object, f.Source, f.Key, value, f.Problem, f.Message)
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("report format %s is not supported", format)
+}
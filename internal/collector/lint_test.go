@@ -0,0 +1,154 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
+	"github.com/stretchr/testify/assert"
+)
+
+var lintAnnotationNames = AnnotationNames{
+	Base:       "sda.se/",
+	Scans:      "scans.sda.se/",
+	Contact:    "contact.sda.se/",
+	DefectDojo: "dd.sda.se/",
+}
+
+func TestKnownAnnotationsCoverConvertedFields(t *testing.T) {
+	image := convertK8ImageToCollectorImage(kubeclient.Image{}, &CollectorImage{}, &lintAnnotationNames, DefaultPrecedence)
+	known := knownAnnotations(&lintAnnotationNames)
+
+	for field, provenance := range image.Provenance {
+		if provenance.Lookup == "" || strings.HasPrefix(provenance.Lookup, "app.kubernetes.io/") {
+			continue
+		}
+		_, ok := known[provenance.Lookup]
+		assert.True(t, ok, "key %s of field %s is not known", provenance.Lookup, field)
+	}
+}
+
+func TestLintTags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tags     map[string]string
+		expected []Finding
+	}{
+		{
+			name: "ValidAndForeignKeys",
+			tags: map[string]string{
+				"contact.sda.se/team":                 "nazgul",
+				"scans.sda.se/skip":                   "true",
+				"scans.sda.se/scan-lifetime-max-days": "90",
+				"scans.sda.se/namespace-filter":       "^mordor-",
+				"app.kubernetes.io/name":              "ring",
+				"other.io/anything":                   "goes",
+			},
+		},
+		{
+			name: "UnknownKeyWithSuggestion",
+			tags: map[string]string{"contact.sda.se/teams": "nazgul"},
+			expected: []Finding{{
+				Object: "pod/mordor/barad-dur", Source: SourcePodAnnotation, Key: "contact.sda.se/teams", Value: "nazgul",
+				Problem: ProblemUnknownKey, Message: "unknown key, did you mean contact.sda.se/team?",
+			}},
+		},
+		{
+			name: "UnknownKeyWithoutSuggestion",
+			tags: map[string]string{"sda.se/something-else": "x"},
+			expected: []Finding{{
+				Object: "pod/mordor/barad-dur", Source: SourcePodAnnotation, Key: "sda.se/something-else", Value: "x",
+				Problem: ProblemUnknownKey, Message: "unknown key",
+			}},
+		},
+		{
+			name: "InvalidBool",
+			tags: map[string]string{"scans.sda.se/skip": "yes"},
+			expected: []Finding{{
+				Object: "pod/mordor/barad-dur", Source: SourcePodAnnotation, Key: "scans.sda.se/skip", Value: "yes",
+				Problem: ProblemInvalidBool, Message: `strconv.ParseBool: parsing "yes": invalid syntax`,
+			}},
+		},
+		{
+			name: "InvalidInt",
+			tags: map[string]string{"scans.sda.se/scan-lifetime-max-days": "90d"},
+			expected: []Finding{{
+				Object: "pod/mordor/barad-dur", Source: SourcePodAnnotation, Key: "scans.sda.se/scan-lifetime-max-days", Value: "90d",
+				Problem: ProblemInvalidInt, Message: `strconv.ParseInt: parsing "90d": invalid syntax`,
+			}},
+		},
+		{
+			name: "InvalidRegex",
+			tags: map[string]string{"scans.sda.se/negated_namespace_filter": "mordor-("},
+			expected: []Finding{{
+				Object: "pod/mordor/barad-dur", Source: SourcePodAnnotation, Key: "scans.sda.se/negated_namespace_filter", Value: "mordor-(",
+				Problem: ProblemInvalidRegex, Message: "error parsing regexp: missing closing ): `mordor-(`",
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := LintTags("pod/mordor/barad-dur", SourcePodAnnotation, tc.tags, &lintAnnotationNames)
+			assert.Equal(t, tc.expected, findings)
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	namespaces := []kubeclient.Namespace{
+		{Name: "shire", Labels: map[string]string{"scans.sda.se/is-scan-malware": "nope"}},
+		{Name: "mordor"},
+	}
+	k8Images := []kubeclient.Image{
+		{
+			NamespaceName: "mordor", PodName: "orc-1", WorkloadKind: "Deployment", WorkloadName: "orc",
+			Annotations:         map[string]string{"scans.sda.se/skip": "maybe"},
+			WorkloadAnnotations: map[string]string{"contact.sda.se/emial": "sauron@mordor"},
+		},
+		// Second container of the same pod, reported once
+		{
+			NamespaceName: "mordor", PodName: "orc-1", WorkloadKind: "Deployment", WorkloadName: "orc",
+			Annotations:         map[string]string{"scans.sda.se/skip": "maybe"},
+			WorkloadAnnotations: map[string]string{"contact.sda.se/emial": "sauron@mordor"},
+		},
+		{NamespaceName: "mordor", PodName: "gollum", WorkloadKind: "Pod", WorkloadName: "gollum", Labels: map[string]string{"sda.se/product": "ring"}},
+	}
+
+	findings := Lint(&namespaces, &k8Images, &lintAnnotationNames)
+
+	var objects []string
+	for _, finding := range findings {
+		objects = append(objects, finding.Object+" "+finding.Key)
+	}
+	assert.Equal(t, []string{
+		"deployment/mordor/orc contact.sda.se/emial",
+		"namespace/shire scans.sda.se/is-scan-malware",
+		"pod/mordor/orc-1 scans.sda.se/skip",
+	}, objects)
+}
+
+func TestWriteLintReport(t *testing.T) {
+	findings := []Finding{{
+		Object: "pod/mordor/orc-1", Source: SourcePodAnnotation, Key: "scans.sda.se/skip", Value: "maybe",
+		Problem: ProblemInvalidBool, Message: "invalid syntax",
+	}}
+
+	var out bytes.Buffer
+	assert.NoError(t, WriteLintReport(&out, findings, "text"))
+	assert.Regexp(t, `pod/mordor/orc-1\s+pod-annotation\s+scans.sda.se/skip\s+"maybe"\s+invalid-bool\s+invalid syntax\n`, out.String())
+
+	out.Reset()
+	assert.NoError(t, WriteLintReport(&out, findings, "json"))
+	var decoded []Finding
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, findings, decoded)
+
+	out.Reset()
+	assert.NoError(t, WriteLintReport(&out, []Finding{}, "text"))
+	assert.Equal(t, "No findings\n", out.String())
+
+	assert.Error(t, WriteLintReport(&out, findings, "xml"))
+}
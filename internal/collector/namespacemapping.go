@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+
+	"github.com/rs/zerolog/log"
+)
+
+// NamespaceMapping assigns teams and their default configuration to namespaces,
+// e.g. the collector.namespacemapping key of the collector configmap
+type NamespaceMapping struct {
+	Teams []TeamMapping `json:"teams"`
+}
+
+type TeamMapping struct {
+	Namespaces []NamespaceMappingEntry `json:"namespaces"`
+
+	// Configurations are keyed by the json names of the CollectorImage fields, e.g. "team", except for
+	// "is_scan_malware", which is also accepted in the misspelled json name "is_scan_maleware"
+	Configurations map[string]string `json:"configurations"`
+}
+
+type NamespaceMappingEntry struct {
+	NamespaceFilter string `json:"namespace_filter"`
+	Description     string `json:"description"`
+
+	namespaceFilter *regexp.Regexp
+}
+
+// ParseNamespaceMapping parses the mapping document and compiles all namespace filters
+func ParseNamespaceMapping(data []byte) (*NamespaceMapping, error) {
+	var mapping NamespaceMapping
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("could not parse namespace mapping: %w", err)
+	}
+
+	for t := range mapping.Teams {
+		for n := range mapping.Teams[t].Namespaces {
+			entry := &mapping.Teams[t].Namespaces[n]
+			filter, err := regexp.Compile(entry.NamespaceFilter)
+			if err != nil {
+				return nil, fmt.Errorf("invalid namespace_filter %q in namespace mapping: %w", entry.NamespaceFilter, err)
+			}
+			entry.namespaceFilter = filter
+		}
+	}
+
+	return &mapping, nil
+}
+
+// match returns the first team and namespace entry whose filter matches the namespace.
+// Teams and their namespaces are evaluated in document order, so the first match always takes precedence.
+func (m *NamespaceMapping) match(namespace string) (*TeamMapping, *NamespaceMappingEntry) {
+	for t := range m.Teams {
+		for n := range m.Teams[t].Namespaces {
+			entry := &m.Teams[t].Namespaces[n]
+			if entry.namespaceFilter != nil && entry.namespaceFilter.MatchString(namespace) {
+				return &m.Teams[t], entry
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Defaults returns the cluster wide defaults overlaid with the configuration of the team matching the namespace.
+// The defaults are returned unchanged if no team matches.
+func (m *NamespaceMapping) Defaults(namespace string, defaults *CollectorImage) *CollectorImage {
+	if m == nil {
+		return defaults
+	}
+
+	team, entry := m.match(namespace)
+	if team == nil {
+		return defaults
+	}
+	log.Debug().Str("namespace", namespace).Str("namespaceFilter", entry.NamespaceFilter).Msg("Applying team configuration from namespace mapping")
+
+	c := maps.Clone(team.Configurations)
+	if c == nil {
+		c = map[string]string{}
+	}
+	if _, ok := c["description"]; !ok && entry.Description != "" {
+		c["description"] = entry.Description
+	}
+	if value, ok := c["is_scan_maleware"]; ok {
+		if _, ok := c["is_scan_malware"]; !ok {
+			c["is_scan_malware"] = value
+		}
+	}
+
+	sources := map[string]string{}
+	for key := range c {
+		sources[key] = SourceTeamMapping
+	}
+	r := newFieldResolver(c, sources, defaults)
+
+	teamDefaults := &CollectorImage{
+		Environment:            r.String("environment", "environment", defaults.Environment),
+		Product:                r.String("product", "product", defaults.Product),
+		Description:            r.String("description", "description", defaults.Description),
+		ContainerType:          r.String("container_type", "container_type", defaults.ContainerType),
+		Skip:                   r.Bool("skip", "skip", defaults.Skip),
+		NamespaceFilter:        r.String("namespace_filter", "namespace_filter", defaults.NamespaceFilter),
+		NamespaceFilterNegated: r.String("namespace_filter_negated", "namespace_filter_negated", defaults.NamespaceFilterNegated),
+		EngagementTags:         r.StringSlice("engagement_tags", "engagement_tags", defaults.EngagementTags),
+
+		Team:  r.String("team", "team", defaults.Team),
+		Slack: r.String("slack", "slack", defaults.Slack),
+		Email: r.String("email", "email", defaults.Email),
+
+		IsScanBaseimageLifetime:          r.Bool("is_scan_baseimage_lifetime", "is_scan_baseimage_lifetime", defaults.IsScanBaseimageLifetime),
+		IsScanDependencyCheck:            r.Bool("is_scan_dependency_check", "is_scan_dependency_check", defaults.IsScanDependencyCheck),
+		IsScanDependencyTrack:            r.Bool("is_scan_dependency_track", "is_scan_dependency_track", defaults.IsScanDependencyTrack),
+		IsScanDistroless:                 r.Bool("is_scan_distroless", "is_scan_distroless", defaults.IsScanDistroless),
+		IsScanLifetime:                   r.Bool("is_scan_lifetime", "is_scan_lifetime", defaults.IsScanLifetime),
+		IsScanMalware:                    r.Bool("is_scan_maleware", "is_scan_malware", defaults.IsScanMalware),
+		IsScanNewVersion:                 r.Bool("is_scan_new_version", "is_scan_new_version", defaults.IsScanNewVersion),
+		IsScanRunAsRoot:                  r.Bool("is_scan_runasroot", "is_scan_runasroot", defaults.IsScanRunAsRoot),
+		IsPotentiallyRunningAsRoot:       r.Bool("is_scan_potentially_running_as_root", "is_scan_potentially_running_as_root", defaults.IsPotentiallyRunningAsRoot),
+		IsScanRunAsPrivileged:            r.Bool("is_scan_run_as_privileged", "is_scan_run_as_privileged", defaults.IsScanRunAsPrivileged),
+		IsPotentiallyRunningAsPrivileged: r.Bool("is_scan_potentially_running_as_privileged", "is_scan_potentially_running_as_privileged", defaults.IsPotentiallyRunningAsPrivileged),
+		ScanLifetimeMaxDays:              r.Int64("scan_lifetime_max_days", "scan_lifetime_max_days", defaults.ScanLifetimeMaxDays),
+	}
+	teamDefaults.Provenance = r.provenance
+
+	return teamDefaults
+}
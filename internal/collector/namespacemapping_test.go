@@ -0,0 +1,152 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
+	"github.com/stretchr/testify/assert"
+)
+
+const testNamespaceMapping = `{
+  "teams": [
+    {
+      "namespaces": [
+        {"namespace_filter": "argo", "description": "used for deployment"},
+        {"namespace_filter": "kube-", "description": "kube-system"}
+      ],
+      "configurations": {
+        "skip": "true",
+        "scan_lifetime_max_days": "90",
+        "is_scan_malware": "false",
+        "slack": "#security-notifications-test",
+        "container_type": "third-party",
+        "team": "operations"
+      }
+    },
+    {
+      "namespaces": [
+        {"namespace_filter": "^argo"},
+        {"namespace_filter": "^shire"}
+      ],
+      "configurations": {
+        "team": "security-journey"
+      }
+    }
+  ]
+}`
+
+func TestParseNamespaceMapping(t *testing.T) {
+	mapping, err := ParseNamespaceMapping([]byte(testNamespaceMapping))
+	assert.NoError(t, err)
+	assert.Len(t, mapping.Teams, 2)
+
+	_, err = ParseNamespaceMapping([]byte(`{"teams": [{"namespaces": [{"namespace_filter": "("}]}]}`))
+	assert.Error(t, err, "Expected error for invalid namespace_filter")
+
+	_, err = ParseNamespaceMapping([]byte(`{"teams": `))
+	assert.Error(t, err, "Expected error for invalid json")
+}
+
+func TestNamespaceMappingDefaults(t *testing.T) {
+	mapping, err := ParseNamespaceMapping([]byte(testNamespaceMapping))
+	assert.NoError(t, err)
+
+	jsonNameMapping, err := ParseNamespaceMapping([]byte(`{"teams": [{"namespaces": [{"namespace_filter": "^mordor"}], "configurations": {"is_scan_maleware": "false"}}]}`))
+	assert.NoError(t, err)
+
+	defaults := CollectorImage{
+		Environment:         "myEnv",
+		ContainerType:       "application",
+		Team:                "nobody",
+		IsScanMalware:       true,
+		ScanLifetimeMaxDays: 120,
+	}
+
+	testCases := []struct {
+		name      string
+		mapping   *NamespaceMapping
+		namespace string
+		expected  CollectorImage
+	}{
+		{
+			name:      "NoMappingReturnsDefaults",
+			mapping:   nil,
+			namespace: "argocd",
+			expected:  defaults,
+		},
+		{
+			name:      "NoMatchReturnsDefaults",
+			mapping:   mapping,
+			namespace: "default",
+			expected:  defaults,
+		},
+		{
+			name:      "FirstMatchingTeamTakesPrecedence",
+			mapping:   mapping,
+			namespace: "argocd",
+			expected: CollectorImage{
+				Environment:         "myEnv",
+				Description:         "used for deployment",
+				ContainerType:       "third-party",
+				Skip:                true,
+				Team:                "operations",
+				Slack:               "#security-notifications-test",
+				IsScanMalware:       false,
+				ScanLifetimeMaxDays: 90,
+			},
+		},
+		{
+			name:      "SecondTeamMatches",
+			mapping:   mapping,
+			namespace: "shire",
+			expected: CollectorImage{
+				Environment:         "myEnv",
+				ContainerType:       "application",
+				Team:                "security-journey",
+				IsScanMalware:       true,
+				ScanLifetimeMaxDays: 120,
+			},
+		},
+		{
+			name:      "MalwareScanByJsonName",
+			mapping:   jsonNameMapping,
+			namespace: "mordor",
+			expected: CollectorImage{
+				Environment:         "myEnv",
+				ContainerType:       "application",
+				Team:                "nobody",
+				IsScanMalware:       false,
+				ScanLifetimeMaxDays: 120,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := *tc.mapping.Defaults(tc.namespace, &defaults)
+			result.Provenance = nil
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestConvertWithNamespaceMapping(t *testing.T) {
+	mapping, err := ParseNamespaceMapping([]byte(testNamespaceMapping))
+	assert.NoError(t, err)
+
+	annotationNames := AnnotationNames{Contact: "contact.sda.se/"}
+	runConfig := RunConfig{NamespaceToTeam: mapping}
+	k8Images := []kubeclient.Image{{
+		Image:         "quay.io/name:tag",
+		NamespaceName: "shire",
+	}, {
+		Image:         "quay.io/name:tag",
+		NamespaceName: "shire-pr-1",
+		Annotations:   map[string]string{"contact.sda.se/team": "team-from-annotation"},
+	}}
+
+	results, err := ConvertImages(&k8Images, &CollectorImage{Team: "nobody"}, &annotationNames, &runConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, "security-journey", (*results)[0].Team, "Expected team mapping to override the defaults")
+	assert.Equal(t, "team-from-annotation", (*results)[1].Team, "Expected annotation to override the team mapping")
+}
@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
+)
+
+// Sources of labels and annotations of an image
+const (
+	SourcePodAnnotation       = "pod-annotation"
+	SourcePodLabel            = "pod-label"
+	SourceWorkloadAnnotation  = "workload-annotation"
+	SourceWorkloadLabel       = "workload-label"
+	SourceNamespaceAnnotation = "namespace-annotation"
+	SourceNamespaceLabel      = "namespace-label"
+)
+
+// DefaultPrecedence lists the sources from the most to the least specific.
+// The team mapping and the cluster wide defaults are only used if no source sets a value.
+var DefaultPrecedence = []string{
+	SourcePodAnnotation,
+	SourcePodLabel,
+	SourceWorkloadAnnotation,
+	SourceWorkloadLabel,
+	SourceNamespaceAnnotation,
+	SourceNamespaceLabel,
+}
+
+// sourceTags returns the labels or annotations of the image for the given source
+func sourceTags(k8Image *kubeclient.Image, source string) map[string]string {
+	switch source {
+	case SourcePodAnnotation:
+		return k8Image.Annotations
+	case SourcePodLabel:
+		return k8Image.Labels
+	case SourceWorkloadAnnotation:
+		return k8Image.WorkloadAnnotations
+	case SourceWorkloadLabel:
+		return k8Image.WorkloadLabels
+	case SourceNamespaceAnnotation:
+		return k8Image.NamespaceAnnotations
+	case SourceNamespaceLabel:
+		return k8Image.NamespaceLabels
+	}
+	return nil
+}
+
+// ValidatePrecedence returns an error for unknown or duplicate sources
+func ValidatePrecedence(precedence []string) error {
+	seen := map[string]bool{}
+	for _, source := range precedence {
+		if !slices.Contains(DefaultPrecedence, source) {
+			return fmt.Errorf("unknown precedence source %q, expected one of %v", source, DefaultPrecedence)
+		}
+		if seen[source] {
+			return fmt.Errorf("duplicate precedence source %q", source)
+		}
+		seen[source] = true
+	}
+	return nil
+}
+
+// mergeTags returns a new map with the labels and annotations of all sources in the precedence,
+// values of earlier sources take precedence. Sources missing in the precedence are ignored.
+// The second map holds the source of every key.
+func mergeTags(k8Image *kubeclient.Image, precedence []string) (map[string]string, map[string]string) {
+	if len(precedence) == 0 {
+		precedence = DefaultPrecedence
+	}
+
+	tags := map[string]string{}
+	sources := map[string]string{}
+	for i := len(precedence) - 1; i >= 0; i-- {
+		sourceTags := sourceTags(k8Image, precedence[i])
+		maps.Copy(tags, sourceTags)
+		for key := range sourceTags {
+			sources[key] = precedence[i]
+		}
+	}
+	return tags, sources
+}
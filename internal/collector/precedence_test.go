@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeTags(t *testing.T) {
+	k8Image := kubeclient.Image{
+		Annotations:          map[string]string{"team": "pod-annotation"},
+		Labels:               map[string]string{"team": "pod-label", "slack": "pod-label"},
+		WorkloadAnnotations:  map[string]string{"team": "workload-annotation", "product": "workload-annotation"},
+		WorkloadLabels:       map[string]string{"team": "workload-label"},
+		NamespaceAnnotations: map[string]string{"team": "namespace-annotation", "email": "namespace-annotation"},
+		NamespaceLabels:      map[string]string{"team": "namespace-label", "email": "namespace-label"},
+	}
+
+	testCases := []struct {
+		name           string
+		precedence     []string
+		expectedResult map[string]string
+	}{
+		{
+			name:       "EmptyPrecedenceUsesDefault",
+			precedence: []string{},
+			expectedResult: map[string]string{
+				"team":    "pod-annotation",
+				"slack":   "pod-label",
+				"product": "workload-annotation",
+				"email":   "namespace-annotation",
+			},
+		},
+		{
+			name:       "NamespaceFirst",
+			precedence: []string{SourceNamespaceLabel, SourceNamespaceAnnotation, SourcePodAnnotation, SourcePodLabel},
+			expectedResult: map[string]string{
+				"team":  "namespace-label",
+				"slack": "pod-label",
+				"email": "namespace-label",
+			},
+		},
+		{
+			name:       "OnlyPodLabels",
+			precedence: []string{SourcePodLabel},
+			expectedResult: map[string]string{
+				"team":  "pod-label",
+				"slack": "pod-label",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, sources := mergeTags(&k8Image, tc.precedence)
+			assert.Equal(t, tc.expectedResult, result)
+			assert.Len(t, sources, len(tc.expectedResult))
+			for key, value := range result {
+				assert.Equal(t, value, sources[key], "Expected %s to be the source of %s", value, key)
+			}
+		})
+	}
+
+	assert.Equal(t, map[string]string{"team": "pod-label", "slack": "pod-label"}, k8Image.Labels, "Expected labels of the pod not to be modified")
+}
+
+func TestValidatePrecedence(t *testing.T) {
+	assert.NoError(t, ValidatePrecedence(DefaultPrecedence))
+	assert.NoError(t, ValidatePrecedence([]string{SourcePodLabel}))
+	assert.Error(t, ValidatePrecedence([]string{"pod-annotations"}), "Expected error for unknown source")
+	assert.Error(t, ValidatePrecedence([]string{SourcePodLabel, SourcePodLabel}), "Expected error for duplicate source")
+}
@@ -0,0 +1,120 @@
+package collector
+
+// Sources of values besides the labels and annotations, see DefaultPrecedence
+const (
+	SourceTeamMapping            = "team-mapping"
+	SourceDefault                = "default"
+	SourceImageFilter            = "image-filter"
+	SourceNamespaceFilter        = "namespace-filter"
+	SourceNamespaceFilterNegated = "negated-namespace-filter"
+	SourceError                  = "error"
+)
+
+// Provenance records where the value of a field came from and the exact key or rule used
+type Provenance struct {
+	Source string `json:"source"`
+	Key    string `json:"key,omitempty"`
+
+	// Lookup is the label/annotation key looked up for the field, Ignored a malformed value found under it
+	Lookup  string        `json:"lookup,omitempty"`
+	Ignored *IgnoredValue `json:"ignored,omitempty"`
+}
+
+// IgnoredValue is a malformed value which fell back to the default
+type IgnoredValue struct {
+	Source string `json:"source"`
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Error  string `json:"error"`
+}
+
+// fieldResolver looks up the fields of a collector image in the tags, falls back to the defaults
+// and records the provenance of every field by its json name
+type fieldResolver struct {
+	tags       map[string]string
+	sources    map[string]string
+	defaults   *CollectorImage
+	provenance map[string]Provenance
+}
+
+func newFieldResolver(tags map[string]string, sources map[string]string, defaults *CollectorImage) *fieldResolver {
+	return &fieldResolver{
+		tags:       tags,
+		sources:    sources,
+		defaults:   defaults,
+		provenance: map[string]Provenance{},
+	}
+}
+
+func (r *fieldResolver) found(field, key string) {
+	r.provenance[field] = Provenance{Source: r.sources[key], Key: key, Lookup: key}
+}
+
+// notFound records the provenance of the default, which may itself come from the team mapping.
+// A malformed value found under the key is recorded as ignored.
+func (r *fieldResolver) notFound(field, key string, err error) {
+	p, ok := r.defaults.Provenance[field]
+	if !ok {
+		p = Provenance{Source: SourceDefault}
+	}
+	p.Lookup = key
+	if err != nil {
+		p.Ignored = &IgnoredValue{Source: r.sources[key], Key: key, Value: r.tags[key], Error: err.Error()}
+	}
+	r.provenance[field] = p
+}
+
+func (r *fieldResolver) String(field, key, default_ string) string {
+	if value, ok := LookupString(r.tags, key); ok {
+		r.found(field, key)
+		return value
+	}
+	r.notFound(field, key, nil)
+	return default_
+}
+
+func (r *fieldResolver) Bool(field, key string, default_ bool) bool {
+	value, ok, err := LookupBool(r.tags, key)
+	if ok && err == nil {
+		r.found(field, key)
+		return value
+	}
+	r.notFound(field, key, err)
+	return default_
+}
+
+func (r *fieldResolver) Int64(field, key string, default_ int64) int64 {
+	value, ok, err := LookupInt64(r.tags, key)
+	if ok && err == nil {
+		r.found(field, key)
+		return value
+	}
+	r.notFound(field, key, err)
+	return default_
+}
+
+func (r *fieldResolver) StringSlice(field, key string, default_ []string) []string {
+	if value, ok := LookupStringSlice(r.tags, key); ok {
+		r.found(field, key)
+		return value
+	}
+	r.notFound(field, key, nil)
+	return default_
+}
+
+// skipProvenance returns the rule which caused the image to be skipped
+func skipProvenance(ci *CollectorImage, runConfig *RunConfig, explicitSkip Provenance) Provenance {
+	switch {
+	case ci.Skip:
+		return explicitSkip
+	case matchNamespaceFilter(ci.NamespaceFilter, ci.Namespace):
+		return Provenance{Source: SourceNamespaceFilter, Key: ci.NamespaceFilter}
+	case matchNamespaceFilter(ci.NamespaceFilterNegated, ci.Namespace):
+		return Provenance{Source: SourceNamespaceFilterNegated, Key: ci.NamespaceFilterNegated}
+	}
+
+	if imageFilter := matchImageFilter(ci, runConfig); imageFilter != nil {
+		return Provenance{Source: SourceImageFilter, Key: imageFilter.Pattern}
+	}
+	return explicitSkip
+}
@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvenance(t *testing.T) {
+	mapping, err := ParseNamespaceMapping([]byte(testNamespaceMapping))
+	assert.NoError(t, err)
+
+	annotationNames := AnnotationNames{
+		Base:       "sda.se/",
+		Scans:      "scans.sda.se/",
+		Contact:    "contact.sda.se/",
+		DefectDojo: "dd.sda.se/",
+	}
+	defaults := CollectorImage{Team: "nobody", Slack: "#default"}
+
+	testCases := []struct {
+		name           string
+		k8Image        kubeclient.Image
+		runConfig      RunConfig
+		expectedResult map[string]Provenance
+	}{
+		{
+			name: "ValuesFromSourcesTeamMappingAndDefaults",
+			k8Image: kubeclient.Image{
+				Image:                "quay.io/name:tag",
+				NamespaceName:        "shire",
+				Annotations:          map[string]string{"scans.sda.se/is-scan-malware": "false"},
+				Labels:               map[string]string{"scans.sda.se/is-scan-malware": "true", "app.kubernetes.io/name": "app"},
+				NamespaceAnnotations: map[string]string{"contact.sda.se/email": "team@example.com"},
+			},
+			runConfig: RunConfig{Provenance: true, NamespaceToTeam: mapping},
+			expectedResult: map[string]Provenance{
+				"is_scan_maleware":       {Source: SourcePodAnnotation, Key: "scans.sda.se/is-scan-malware", Lookup: "scans.sda.se/is-scan-malware"},
+				"app_kubernetes_io_name": {Source: SourcePodLabel, Key: "app.kubernetes.io/name", Lookup: "app.kubernetes.io/name"},
+				"email":                  {Source: SourceNamespaceAnnotation, Key: "contact.sda.se/email", Lookup: "contact.sda.se/email"},
+				"team":                   {Source: SourceTeamMapping, Key: "team", Lookup: "contact.sda.se/team"},
+				"slack":                  {Source: SourceDefault, Lookup: "contact.sda.se/slack"},
+				"skip":                   {Source: SourceDefault, Lookup: "scans.sda.se/skip"},
+			},
+		},
+		{
+			name: "MalformedValueFallsBackToDefault",
+			k8Image: kubeclient.Image{
+				Image:       "quay.io/name:tag",
+				Annotations: map[string]string{"scans.sda.se/skip": "yes"},
+			},
+			runConfig: RunConfig{Provenance: true},
+			expectedResult: map[string]Provenance{
+				"skip": {
+					Source:  SourceDefault,
+					Lookup:  "scans.sda.se/skip",
+					Ignored: &IgnoredValue{Source: SourcePodAnnotation, Key: "scans.sda.se/skip", Value: "yes", Error: `strconv.ParseBool: parsing "yes": invalid syntax`},
+				},
+			},
+		},
+		{
+			name: "ExplicitSkip",
+			k8Image: kubeclient.Image{
+				Image:       "quay.io/name:tag",
+				Annotations: map[string]string{"scans.sda.se/skip": "true", "scans.sda.se/namespace-filter": ".*"},
+			},
+			runConfig: RunConfig{Provenance: true},
+			expectedResult: map[string]Provenance{
+				"skip": {Source: SourcePodAnnotation, Key: "scans.sda.se/skip", Lookup: "scans.sda.se/skip"},
+			},
+		},
+		{
+			name: "SkipByNamespaceFilter",
+			k8Image: kubeclient.Image{
+				Image:         "quay.io/name:tag",
+				NamespaceName: "shire-pr-1",
+				Annotations:   map[string]string{"scans.sda.se/negated_namespace_filter": "-pr-"},
+			},
+			runConfig: RunConfig{Provenance: true},
+			expectedResult: map[string]Provenance{
+				"skip": {Source: SourceNamespaceFilterNegated, Key: "-pr-"},
+			},
+		},
+		{
+			name: "SkipByImageFilter",
+			k8Image: kubeclient.Image{
+				Image: "quay.io/name:tag",
+			},
+			runConfig: RunConfig{Provenance: true, ImageFilter: []string{"docker.io", "quay.io/"}},
+			expectedResult: map[string]Provenance{
+				"skip": {Source: SourceImageFilter, Key: "quay.io/"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.NoError(t, tc.runConfig.CompileImageFilters())
+			results, err := ConvertImages(&[]kubeclient.Image{tc.k8Image}, &defaults, &annotationNames, &tc.runConfig)
+			assert.NoError(t, err)
+
+			provenance := (*results)[0].Provenance
+			for field, expected := range tc.expectedResult {
+				assert.Equal(t, expected, provenance[field], "Unexpected provenance of %s", field)
+			}
+		})
+	}
+
+	results, err := ConvertImages(&[]kubeclient.Image{{Image: "quay.io/name:tag"}}, &defaults, &annotationNames, &RunConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, (*results)[0].Provenance, "Expected no provenance if not enabled")
+}
@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultRegistry  = "docker.io"
+	defaultNamespace = "library"
+	defaultTag       = "latest"
+)
+
+// ImageReference is a normalised image reference, e.g. nginx is docker.io/library/nginx:latest
+type ImageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseImageReference splits the image into registry, repository, tag and digest.
+// Docker Hub references get the implicit docker.io registry and library/ namespace,
+// references with neither tag nor digest the implicit latest tag.
+func ParseImageReference(image string) ImageReference {
+	var ref ImageReference
+	if image == "" {
+		return ref
+	}
+
+	name, digest, _ := strings.Cut(image, "@")
+	ref.Digest = digest
+
+	// A tag is separated by the last colon after the last slash, other colons belong to the registry port
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		ref.Tag = name[idx+1:]
+		name = name[:idx]
+	}
+
+	// The first component is a registry if it looks like a host name
+	registry, repository, found := strings.Cut(name, "/")
+	if !found || (!strings.ContainsAny(registry, ".:") && registry != "localhost") {
+		registry = defaultRegistry
+		repository = name
+	}
+	if registry == "index.docker.io" {
+		registry = defaultRegistry
+	}
+	if registry == defaultRegistry && !strings.Contains(repository, "/") {
+		repository = defaultNamespace + "/" + repository
+	}
+
+	ref.Registry = registry
+	ref.Repository = repository
+
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+
+	return ref
+}
+
+// String returns the fully qualified reference
+func (r ImageReference) String() string {
+	if r.Repository == "" {
+		return ""
+	}
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// digestFromImageId returns the digest of an image id in the repository@digest format. A bare sha256:... id is the
+// id of the local image config, not a digest of the registry manifest, so it has no digest.
+func digestFromImageId(imageId string) string {
+	_, digest, found := strings.Cut(imageId, "@")
+	if !found {
+		return ""
+	}
+	return digest
+}
+
+// PackageURL returns the purl of the image, e.g. pkg:oci/nginx@sha256%3Aabc?repository_url=docker.io/library/nginx&tag=1.25.
+// The version is the digest, images without digest have no version.
+func (r ImageReference) PackageURL() string {
+	if r.Repository == "" {
+		return ""
+	}
+
+	name := r.Repository[strings.LastIndex(r.Repository, "/")+1:]
+	purl := "pkg:oci/" + purlEscape(strings.ToLower(name), false)
+	if r.Digest != "" {
+		purl += "@" + purlEscape(r.Digest, false)
+	}
+
+	// Qualifiers are sorted by key
+	purl += "?repository_url=" + purlEscape(r.Registry+"/"+r.Repository, true)
+	if r.Tag != "" {
+		purl += "&tag=" + purlEscape(r.Tag, false)
+	}
+	return purl
+}
+
+// purlEscape percent-encodes all but the unreserved characters, and slashes if allowed
+func purlEscape(s string, allowSlash bool) string {
+	var escaped strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' || c == '/' && allowSlash {
+			escaped.WriteByte(c)
+			continue
+		}
+		escaped.WriteString(fmt.Sprintf("%%%02X", c))
+	}
+	return escaped.String()
+}
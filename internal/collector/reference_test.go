@@ -0,0 +1,131 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImageReference(t *testing.T) {
+	testCases := []struct {
+		name           string
+		image          string
+		expectedResult ImageReference
+		expectedString string
+	}{
+		{
+			name:           "EmptyImage",
+			image:          "",
+			expectedResult: ImageReference{},
+			expectedString: "",
+		},
+		{
+			name:           "OfficialImageWithoutTag",
+			image:          "nginx",
+			expectedResult: ImageReference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"},
+			expectedString: "docker.io/library/nginx:latest",
+		},
+		{
+			name:           "FullyQualifiedOfficialImage",
+			image:          "docker.io/library/nginx:latest",
+			expectedResult: ImageReference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"},
+			expectedString: "docker.io/library/nginx:latest",
+		},
+		{
+			name:           "DockerHubUserImage",
+			image:          "minio/operator:v5.0.11",
+			expectedResult: ImageReference{Registry: "docker.io", Repository: "minio/operator", Tag: "v5.0.11"},
+			expectedString: "docker.io/minio/operator:v5.0.11",
+		},
+		{
+			name:           "LegacyDockerHubRegistry",
+			image:          "index.docker.io/redis:7",
+			expectedResult: ImageReference{Registry: "docker.io", Repository: "library/redis", Tag: "7"},
+			expectedString: "docker.io/library/redis:7",
+		},
+		{
+			name:           "RegistryWithPort",
+			image:          "localhost:5000/team/app",
+			expectedResult: ImageReference{Registry: "localhost:5000", Repository: "team/app", Tag: "latest"},
+			expectedString: "localhost:5000/team/app:latest",
+		},
+		{
+			name:           "LocalhostRegistry",
+			image:          "localhost/app:1",
+			expectedResult: ImageReference{Registry: "localhost", Repository: "app", Tag: "1"},
+			expectedString: "localhost/app:1",
+		},
+		{
+			name:           "DigestOnly",
+			image:          "registry.k8s.io/coredns/coredns@sha256:1234",
+			expectedResult: ImageReference{Registry: "registry.k8s.io", Repository: "coredns/coredns", Digest: "sha256:1234"},
+			expectedString: "registry.k8s.io/coredns/coredns@sha256:1234",
+		},
+		{
+			name:           "TagAndDigest",
+			image:          "quay.io/argoproj/argocd:v2.10.0@sha256:1234",
+			expectedResult: ImageReference{Registry: "quay.io", Repository: "argoproj/argocd", Tag: "v2.10.0", Digest: "sha256:1234"},
+			expectedString: "quay.io/argoproj/argocd:v2.10.0@sha256:1234",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := ParseImageReference(tc.image)
+			assert.Equal(t, tc.expectedResult, result)
+			assert.Equal(t, tc.expectedString, result.String())
+		})
+	}
+}
+
+func TestSetImageReferenceDigestFromImageId(t *testing.T) {
+	ci := CollectorImage{
+		Image:   "nginx:1.25",
+		ImageId: "docker.io/library/nginx@sha256:abcd",
+	}
+	setImageReference(&ci)
+	assert.Equal(t, "docker.io", ci.Registry)
+	assert.Equal(t, "library/nginx", ci.Repository)
+	assert.Equal(t, "1.25", ci.Tag)
+	assert.Equal(t, "sha256:abcd", ci.Digest)
+
+	ci = CollectorImage{
+		Image:   "nginx@sha256:1111",
+		ImageId: "docker.io/library/nginx@sha256:abcd",
+	}
+	setImageReference(&ci)
+	assert.Equal(t, "sha256:1111", ci.Digest, "Expected the digest of the reference to take precedence")
+
+	ci = CollectorImage{
+		Image:   "nginx:1.25",
+		ImageId: "sha256:abcd",
+	}
+	setImageReference(&ci)
+	assert.Empty(t, ci.Digest, "Expected no digest for the local image config id")
+	assert.Equal(t, "sha256:abcd", ci.ImageId)
+
+	ci = CollectorImage{
+		Image:   "nginx:1.25",
+		ImageId: "nginx:1.25",
+	}
+	setImageReference(&ci)
+	assert.Empty(t, ci.Digest)
+}
+
+func TestPackageURL(t *testing.T) {
+	testCases := []struct {
+		image        string
+		expectedPURL string
+	}{
+		{"", ""},
+		{"nginx:1.25@sha256:abcd", "pkg:oci/nginx@sha256%3Aabcd?repository_url=docker.io/library/nginx&tag=1.25"},
+		{"quay.io/SDA-SE/Ring@sha256:abcd", "pkg:oci/ring@sha256%3Aabcd?repository_url=quay.io/SDA-SE/Ring"},
+		{"localhost:5000/app:v1+build", "pkg:oci/app?repository_url=localhost%3A5000/app&tag=v1%2Bbuild"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.image, func(t *testing.T) {
+			assert.Equal(t, tc.expectedPURL, ParseImageReference(tc.image).PackageURL())
+		})
+	}
+}
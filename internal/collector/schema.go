@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// jsonSchemaDialect is the JSON Schema version of JSONSchema
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// JSONSchema returns the JSON Schema of the json output of Store, generated from the fields of CollectorImage or
+// AggregatedImage. Optionally the images are wrapped in an Envelope of the current SchemaVersion.
+func JSONSchema(aggregate, envelope bool) map[string]any {
+	title := "CollectorImage"
+	imageType := reflect.TypeOf(CollectorImage{})
+	if aggregate {
+		title = "AggregatedImage"
+		imageType = reflect.TypeOf(AggregatedImage{})
+	}
+
+	schema := typeSchema(reflect.SliceOf(imageType))
+	if envelope {
+		images := schema
+		schema = typeSchema(reflect.TypeOf(EnvelopeMetadata{}))
+		properties := schema["properties"].(map[string]any)
+		properties["schema_version"].(map[string]any)["const"] = SchemaVersion
+		properties["images"] = images
+		schema["required"] = append(schema["required"].([]string), "images")
+		title = "Envelope of " + title
+	}
+
+	schema["$schema"] = jsonSchemaDialect
+	schema["title"] = title
+	return schema
+}
+
+// typeSchema returns the schema of the json encoding of the type, nil slices and maps are encoded as null
+func typeSchema(t reflect.Type) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := typeSchema(t.Elem())
+		if jsonType, ok := schema["type"].(string); ok {
+			schema["type"] = []string{jsonType, "null"}
+		}
+		return schema
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": []string{"array", "null"}, "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		addFieldSchemas(t, properties, &required)
+		return map[string]any{"type": "object", "properties": properties, "required": required, "additionalProperties": false}
+	}
+	// Any value, e.g. of an interface
+	return map[string]any{}
+}
+
+// addFieldSchemas adds the schemas of the fields of the struct, including the ones of embedded structs.
+// Fields which are not omitted if empty are required.
+func addFieldSchemas(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addFieldSchemas(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = typeSchema(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
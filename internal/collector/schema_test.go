@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schema := JSONSchema(false, false)
+	assert.Equal(t, jsonSchemaDialect, schema["$schema"])
+	assert.Equal(t, "CollectorImage", schema["title"])
+	assert.Equal(t, []string{"array", "null"}, schema["type"])
+
+	items := schema["items"].(map[string]any)
+	properties := items["properties"].(map[string]any)
+	required := items["required"].([]string)
+	assert.Equal(t, false, items["additionalProperties"])
+
+	for name := range jsonFieldNames(reflect.TypeOf(CollectorImage{})) {
+		assert.Contains(t, properties, name)
+	}
+	assert.Equal(t, map[string]any{"type": "string"}, properties["image"])
+	assert.Equal(t, map[string]any{"type": "boolean"}, properties["skip"])
+	assert.Equal(t, map[string]any{"type": "integer"}, properties["scan_lifetime_max_days"])
+	assert.Equal(t, map[string]any{"type": []string{"array", "null"}, "items": map[string]any{"type": "string"}}, properties["engagement_tags"])
+
+	provenance := properties["provenance"].(map[string]any)["additionalProperties"].(map[string]any)
+	ignored := provenance["properties"].(map[string]any)["ignored"].(map[string]any)
+	assert.Equal(t, []string{"object", "null"}, ignored["type"])
+
+	assert.Contains(t, required, "image")
+	assert.NotContains(t, required, "cluster")
+	assert.NotContains(t, required, "error")
+
+	// Every field of an image is part of the schema and every required field is part of the image
+	data, err := json.Marshal(encoderImages[0])
+	assert.NoError(t, err)
+	var image map[string]any
+	assert.NoError(t, json.Unmarshal(data, &image))
+	for name := range image {
+		assert.Contains(t, properties, name)
+	}
+	for _, name := range required {
+		assert.Contains(t, image, name)
+	}
+}
+
+func TestJSONSchemaAggregated(t *testing.T) {
+	schema := JSONSchema(true, false)
+	assert.Equal(t, "AggregatedImage", schema["title"])
+
+	items := schema["items"].(map[string]any)
+	properties := items["properties"].(map[string]any)
+	assert.Contains(t, properties, "image")
+	assert.Contains(t, properties, "locations")
+	assert.Contains(t, properties, "replicas")
+	assert.NotContains(t, properties, "CollectorImage")
+}
+
+func TestJSONSchemaEnvelope(t *testing.T) {
+	schema := JSONSchema(false, true)
+	assert.Equal(t, "Envelope of CollectorImage", schema["title"])
+	assert.Equal(t, "object", schema["type"])
+
+	properties := schema["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "string", "const": SchemaVersion}, properties["schema_version"])
+	assert.Equal(t, map[string]any{"type": "string", "format": "date-time"}, properties["generated_at"])
+	assert.Equal(t, "CollectorImage", JSONSchema(false, false)["title"], "Generating a schema must not change other schemas")
+	assert.Contains(t, properties["images"].(map[string]any), "items")
+	assert.Equal(t, []string{"schema_version", "collector_version", "environment", "generated_at", "images"}, schema["required"])
+}
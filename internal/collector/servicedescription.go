@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"sort"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
+)
+
+// ServiceDescription describes the services of a namespace by its team, description, product and contact channels
+type ServiceDescription struct {
+	// Name of the cluster, only set if several clusters are collected in one run
+	Cluster     string `json:"cluster,omitempty"`
+	Environment string `json:"environment"`
+	Namespace   string `json:"namespace"`
+	Team        string `json:"team"`
+	Description string `json:"description"`
+	Product     string `json:"product"`
+	Slack       string `json:"slack"`
+	Email       string `json:"email"`
+}
+
+// ConvertServiceDescriptions describes every namespace, ordered by name. The fields are resolved from the namespace
+// labels and annotations, the namespace mapping and the defaults like the ones of the images in the namespace.
+func ConvertServiceDescriptions(namespaces *[]kubeclient.Namespace, defaults *CollectorImage, annotationNames *AnnotationNames, runConfig *RunConfig) *[]ServiceDescription {
+	descriptions := []ServiceDescription{}
+
+	for _, namespace := range *namespaces {
+		k8Image := kubeclient.Image{
+			NamespaceName:        namespace.Name,
+			NamespaceLabels:      namespace.Labels,
+			NamespaceAnnotations: namespace.Annotations,
+		}
+		teamDefaults := runConfig.NamespaceToTeam.Defaults(namespace.Name, defaults)
+		ci := convertK8ImageToCollectorImage(k8Image, teamDefaults, annotationNames, runConfig.Precedence)
+
+		descriptions = append(descriptions, ServiceDescription{
+			Environment: ci.Environment,
+			Namespace:   namespace.Name,
+			Team:        ci.Team,
+			Description: ci.Description,
+			Product:     ci.Product,
+			Slack:       ci.Slack,
+			Email:       ci.Email,
+		})
+	}
+
+	sort.SliceStable(descriptions, func(i, j int) bool { return descriptions[i].Namespace < descriptions[j].Namespace })
+
+	return &descriptions
+}
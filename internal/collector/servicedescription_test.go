@@ -0,0 +1,42 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/SDA-SE/image-metadata-collector/internal/pkg/kubeclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertServiceDescriptions(t *testing.T) {
+	mapping, err := ParseNamespaceMapping([]byte(testNamespaceMapping))
+	assert.NoError(t, err)
+
+	namespaces := []kubeclient.Namespace{
+		{
+			Name: "shire",
+			Annotations: map[string]string{
+				"contact.sdase.org/team":  "hobbits",
+				"contact.sdase.org/slack": "#shire",
+				"contact.sdase.org/email": "frodo@shire.me",
+				"sdase.org/description":   "Second breakfast",
+			},
+			Labels: map[string]string{"sdase.org/product": "ring"},
+		},
+		{Name: "argocd"},
+		{Name: "mordor"},
+	}
+	annotationNames := AnnotationNames{Base: "sdase.org/", Contact: "contact.sdase.org/"}
+	defaults := CollectorImage{Environment: "middle-earth", Email: "gandalf@valinor.me"}
+	runConfig := RunConfig{Precedence: DefaultPrecedence, NamespaceToTeam: mapping}
+
+	descriptions := ConvertServiceDescriptions(&namespaces, &defaults, &annotationNames, &runConfig)
+
+	assert.Equal(t, []ServiceDescription{
+		{Environment: "middle-earth", Namespace: "argocd", Team: "operations", Description: "used for deployment", Slack: "#security-notifications-test", Email: "gandalf@valinor.me"},
+		{Environment: "middle-earth", Namespace: "mordor", Email: "gandalf@valinor.me"},
+		{Environment: "middle-earth", Namespace: "shire", Team: "hobbits", Description: "Second breakfast", Product: "ring", Slack: "#shire", Email: "frodo@shire.me"},
+	}, *descriptions)
+
+	descriptions = ConvertServiceDescriptions(&[]kubeclient.Namespace{}, &defaults, &annotationNames, &runConfig)
+	assert.Equal(t, []ServiceDescription{}, *descriptions)
+}
@@ -0,0 +1,224 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OutputFormatSPDXJSON is an SPDX 2.3 document, see https://spdx.github.io/spdx-spec/v2.3/
+const OutputFormatSPDXJSON = "spdx-json"
+
+// spdxNow is the creation time of SPDX documents
+var spdxNow = time.Now
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	Supplier              string            `json:"supplier"`
+	Originator            string            `json:"originator,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	Checksums             []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose"`
+	Comment               string            `json:"comment,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func init() {
+	RegisterEncoder(OutputFormatSPDXJSON, func(options *EncoderOptions) (Encoder, error) {
+		return Encoder{Extension: ".spdx.json", Marshal: func(v any) ([]byte, error) {
+			document, err := newSPDXDocument(v)
+			if err != nil {
+				return nil, err
+			}
+			return jsonIndentMarshalUnescaped(document)
+		}}, nil
+	})
+}
+
+// newSPDXDocument describes the environments of the images with a package per unique image digest,
+// images without digest are merged by their reference. Images which could not be collected are left out.
+func newSPDXDocument(v any) (*spdxDocument, error) {
+	images, err := storedImages(v)
+	if err != nil {
+		return nil, err
+	}
+
+	document := &spdxDocument{
+		SPDXVersion: "SPDX-2.3",
+		DataLicense: "CC0-1.0",
+		SPDXID:      "SPDXRef-DOCUMENT",
+		CreationInfo: spdxCreationInfo{
+			Created:  spdxNow().UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + toolName},
+		},
+		Packages:      []spdxPackage{},
+		Relationships: []spdxRelationship{},
+	}
+
+	var environments []string
+	namespacesByID := map[string][]string{}
+	indexByKey := map[string]int{}
+	ids := map[string]bool{}
+
+	for _, image := range images {
+		if image.Error != "" {
+			continue
+		}
+		if image.Environment != "" && !slices.Contains(environments, image.Environment) {
+			environments = append(environments, image.Environment)
+		}
+
+		ref := ImageReference{Registry: image.Registry, Repository: image.Repository, Tag: image.Tag, Digest: image.Digest}
+		key := ref.Digest
+		if key == "" {
+			key = ref.String()
+		}
+		if key == "" {
+			key = image.Image
+		}
+
+		i, ok := indexByKey[key]
+		if !ok {
+			i = len(document.Packages)
+			indexByKey[key] = i
+			document.Packages = append(document.Packages, newSPDXPackage(&image, ref, spdxID(key, ids)))
+		}
+
+		// The supplier is the team of the first image which has one
+		pkg := &document.Packages[i]
+		if pkg.Supplier == spdxNoAssertion {
+			if supplier := spdxSupplier(image.Team, image.Email); supplier != "" {
+				pkg.Supplier = supplier
+				pkg.Originator = supplier
+			}
+		}
+		if !slices.Contains(namespacesByID[pkg.SPDXID], image.Namespace) {
+			namespacesByID[pkg.SPDXID] = append(namespacesByID[pkg.SPDXID], image.Namespace)
+		}
+	}
+
+	for i := range document.Packages {
+		pkg := &document.Packages[i]
+		namespaces := namespacesByID[pkg.SPDXID]
+		sort.Strings(namespaces)
+		pkg.Comment = "Namespaces: " + strings.Join(namespaces, ", ")
+
+		document.Relationships = append(document.Relationships, spdxRelationship{
+			SPDXElementID:      document.SPDXID,
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: pkg.SPDXID,
+		})
+	}
+
+	sort.Strings(environments)
+	document.Name = strings.Join(environments, ",")
+	if document.Name == "" {
+		document.Name = "images"
+	}
+	document.DocumentNamespace = spdxDocumentNamespace(document)
+
+	return document, nil
+}
+
+const spdxNoAssertion = "NOASSERTION"
+
+func newSPDXPackage(image *CollectorImage, ref ImageReference, id string) spdxPackage {
+	pkg := spdxPackage{
+		SPDXID:                id,
+		Name:                  image.Image,
+		VersionInfo:           ref.Tag,
+		Supplier:              spdxNoAssertion,
+		DownloadLocation:      spdxNoAssertion,
+		PrimaryPackagePurpose: "CONTAINER",
+	}
+	if ref.Repository != "" {
+		pkg.Name = ref.Registry + "/" + ref.Repository
+	}
+	if pkg.VersionInfo == "" {
+		pkg.VersionInfo = ref.Digest
+	}
+	if hash, ok := digestHash(ref.Digest); ok {
+		pkg.Checksums = []spdxChecksum{{Algorithm: strings.ReplaceAll(hash.Alg, "-", ""), ChecksumValue: hash.Content}}
+	}
+	if purl := ref.PackageURL(); purl != "" {
+		pkg.ExternalRefs = []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: purl}}
+	}
+	return pkg
+}
+
+// spdxSupplier returns the team as organization with the email, e.g. 'Organization: hobbits (frodo@shire.me)'
+func spdxSupplier(team, email string) string {
+	switch {
+	case team != "" && email != "":
+		return "Organization: " + team + " (" + email + ")"
+	case team != "":
+		return "Organization: " + team
+	case email != "":
+		return "Organization: " + email
+	}
+	return ""
+}
+
+var spdxIDInvalid = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// spdxID returns a unique SPDX identifier of the key, which only consists of letters, numbers, dots and dashes
+func spdxID(key string, ids map[string]bool) string {
+	id := "SPDXRef-Image-" + strings.Trim(spdxIDInvalid.ReplaceAllString(key, "-"), "-")
+	unique := id
+	for i := 2; ids[unique]; i++ {
+		unique = id + "-" + strconv.Itoa(i)
+	}
+	ids[unique] = true
+	return unique
+}
+
+// spdxDocumentNamespace is unique for the name, creation time and packages of the document
+func spdxDocumentNamespace(document *spdxDocument) string {
+	hash := sha256.New()
+	hash.Write([]byte(document.Name + "\n" + document.CreationInfo.Created + "\n"))
+	for _, pkg := range document.Packages {
+		hash.Write([]byte(pkg.SPDXID + "\n"))
+	}
+	return "https://spdx.org/spdxdocs/" + toolName + "/" + spdxIDInvalid.ReplaceAllString(document.Name, "-") + "-" + hex.EncodeToString(hash.Sum(nil))
+}
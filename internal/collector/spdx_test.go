@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSPDXJSON(t *testing.T) {
+	spdxNow = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600)) }
+	defer func() { spdxNow = time.Now }()
+
+	images := []CollectorImage{
+		{Namespace: "shire", Environment: "middle-earth", Image: "quay.io/sdase/ring:1.0", Registry: "quay.io", Repository: "sdase/ring", Tag: "1.0", Digest: "sha256:abcd"},
+		{Namespace: "rivendell", Environment: "middle-earth", Image: "quay.io/sdase/ring:1.0", Registry: "quay.io", Repository: "sdase/ring", Tag: "1.0", Digest: "sha256:abcd", Team: "elves", Email: "elrond@rivendell.me"},
+		{Namespace: "shire", Environment: "middle-earth", Image: "pipe:2", Registry: "docker.io", Repository: "library/pipe", Tag: "2", Team: "hobbits"},
+		{Namespace: "shire", Environment: "middle-earth", Image: "quay.io/sdase/ring:1.1", Registry: "quay.io", Repository: "sdase/ring", Tag: "1.1", Digest: "sha256:abcd"},
+		{Namespace: "mordor", Error: "pods is forbidden", Skip: true},
+	}
+
+	encoder, err := NewEncoder(OutputFormatSPDXJSON, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, ".spdx.json", encoder.Extension)
+
+	var data bytes.Buffer
+	assert.NoError(t, Store(&images, &data, encoder.Marshal))
+
+	var document spdxDocument
+	assert.NoError(t, json.Unmarshal(data.Bytes(), &document))
+	assert.Equal(t, "SPDX-2.3", document.SPDXVersion)
+	assert.Equal(t, "CC0-1.0", document.DataLicense)
+	assert.Equal(t, "middle-earth", document.Name)
+	assert.Equal(t, "2024-03-01T11:00:00Z", document.CreationInfo.Created)
+	assert.Equal(t, []string{"Tool: image-metadata-collector"}, document.CreationInfo.Creators)
+	assert.True(t, strings.HasPrefix(document.DocumentNamespace, "https://spdx.org/spdxdocs/image-metadata-collector/middle-earth-"))
+
+	assert.Len(t, document.Packages, 2, "Expected one package per digest, images without digest by reference")
+
+	ring := document.Packages[0]
+	assert.Equal(t, "SPDXRef-Image-sha256-abcd", ring.SPDXID)
+	assert.Equal(t, "quay.io/sdase/ring", ring.Name)
+	assert.Equal(t, "1.0", ring.VersionInfo)
+	assert.Equal(t, "Organization: elves (elrond@rivendell.me)", ring.Supplier)
+	assert.Equal(t, "Organization: elves (elrond@rivendell.me)", ring.Originator)
+	assert.Equal(t, "NOASSERTION", ring.DownloadLocation)
+	assert.Equal(t, "CONTAINER", ring.PrimaryPackagePurpose)
+	assert.Equal(t, []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: "abcd"}}, ring.Checksums)
+	assert.Equal(t, []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl",
+		ReferenceLocator: "pkg:oci/ring@sha256%3Aabcd?repository_url=quay.io/sdase/ring&tag=1.0"}}, ring.ExternalRefs)
+	assert.Equal(t, "Namespaces: rivendell, shire", ring.Comment)
+
+	pipe := document.Packages[1]
+	assert.Equal(t, "SPDXRef-Image-docker.io-library-pipe-2", pipe.SPDXID)
+	assert.Equal(t, "Organization: hobbits", pipe.Supplier)
+	assert.Empty(t, pipe.Checksums)
+
+	assert.Equal(t, []spdxRelationship{
+		{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: ring.SPDXID},
+		{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: pipe.SPDXID},
+	}, document.Relationships)
+
+	// The document namespace is unique per content and creation time
+	var again bytes.Buffer
+	assert.NoError(t, Store(&images, &again, encoder.Marshal))
+	assert.Equal(t, data.String(), again.String())
+}
+
+func TestSPDXID(t *testing.T) {
+	ids := map[string]bool{}
+	assert.Equal(t, "SPDXRef-Image-a-b", spdxID("a/b", ids))
+	assert.Equal(t, "SPDXRef-Image-a-b-2", spdxID("a:b", ids))
+	assert.Equal(t, "SPDXRef-Image-a-b-3", spdxID("a_b", ids))
+}
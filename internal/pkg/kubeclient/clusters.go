@@ -0,0 +1,69 @@
+package kubeclient
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cluster is one of several clusters collected in one run, mapped to an environment
+type Cluster struct {
+	Name        string
+	Environment string
+	KubeConfig  KubeConfig
+}
+
+// Clusters returns the clusters of the contexts, given as '<context>=<environment>' or '<context>'
+// using the context name as environment, and of the kubeconfig files in the ConfigDir, using their
+// current context and the file name without extension as name and environment.
+// Returns no clusters if neither contexts nor a directory are configured.
+func (cfg *KubeConfig) Clusters() ([]Cluster, error) {
+	var clusters []Cluster
+
+	if len(cfg.Input) > 0 && (len(cfg.Contexts) > 0 || cfg.ConfigDir != "") {
+		return nil, fmt.Errorf("offline input can't be combined with several clusters")
+	}
+
+	for _, context := range cfg.Contexts {
+		name, environment, found := strings.Cut(context, "=")
+		if !found {
+			environment = name
+		}
+		if name == "" || environment == "" {
+			return nil, fmt.Errorf("invalid context %q, expected '<context>=<environment>'", context)
+		}
+
+		clusterConfig := *cfg
+		clusterConfig.Context = name
+		clusters = append(clusters, Cluster{Name: name, Environment: environment, KubeConfig: clusterConfig})
+	}
+
+	if cfg.ConfigDir != "" {
+		entries, err := os.ReadDir(cfg.ConfigDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+
+			clusterConfig := *cfg
+			clusterConfig.ConfigFile = filepath.Join(cfg.ConfigDir, entry.Name())
+			clusterConfig.Context = ""
+			clusters = append(clusters, Cluster{Name: name, Environment: name, KubeConfig: clusterConfig})
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, cluster := range clusters {
+		if seen[cluster.Name] {
+			return nil, fmt.Errorf("cluster %s is configured more than once", cluster.Name)
+		}
+		seen[cluster.Name] = true
+	}
+
+	return clusters, nil
+}
@@ -0,0 +1,86 @@
+package kubeclient
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestClusters(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"staging.yaml", "prod", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte{}, 0600); err != nil {
+			t.Fatalf("Got an error=%v\n", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "subdir"), 0700); err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+
+	testCases := []struct {
+		name             string
+		cfg              KubeConfig
+		expectedClusters []Cluster
+		expectError      bool
+	}{
+		{
+			name: "NoClusters",
+			cfg:  KubeConfig{ConfigFile: "config", Context: "kind"},
+		},
+		{
+			name: "Contexts",
+			cfg:  KubeConfig{ConfigFile: "config", PageSize: 10, Contexts: []string{"kind-a=dev", "kind-b"}},
+			expectedClusters: []Cluster{
+				{Name: "kind-a", Environment: "dev", KubeConfig: KubeConfig{ConfigFile: "config", Context: "kind-a", PageSize: 10, Contexts: []string{"kind-a=dev", "kind-b"}}},
+				{Name: "kind-b", Environment: "kind-b", KubeConfig: KubeConfig{ConfigFile: "config", Context: "kind-b", PageSize: 10, Contexts: []string{"kind-a=dev", "kind-b"}}},
+			},
+		},
+		{
+			name: "ConfigDir",
+			cfg:  KubeConfig{ConfigFile: "config", Context: "kind", ConfigDir: dir},
+			expectedClusters: []Cluster{
+				{Name: "prod", Environment: "prod", KubeConfig: KubeConfig{ConfigFile: filepath.Join(dir, "prod"), ConfigDir: dir}},
+				{Name: "staging", Environment: "staging", KubeConfig: KubeConfig{ConfigFile: filepath.Join(dir, "staging.yaml"), ConfigDir: dir}},
+			},
+		},
+		{
+			name:        "InvalidContext",
+			cfg:         KubeConfig{Contexts: []string{"kind-a="}},
+			expectError: true,
+		},
+		{
+			name:        "DuplicateCluster",
+			cfg:         KubeConfig{Contexts: []string{"prod=a"}, ConfigDir: dir},
+			expectError: true,
+		},
+		{
+			name:        "OfflineInput",
+			cfg:         KubeConfig{Contexts: []string{"kind-a"}, Input: []string{"pods.json"}},
+			expectError: true,
+		},
+		{
+			name:        "MissingConfigDir",
+			cfg:         KubeConfig{ConfigDir: filepath.Join(dir, "missing")},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clusters, err := tc.cfg.Clusters()
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("Expected an error but got none\n")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got an error=%v\n", err)
+			}
+			if !reflect.DeepEqual(clusters, tc.expectedClusters) {
+				t.Fatalf("Expected clusters %v but got %v\n", tc.expectedClusters, clusters)
+			}
+		})
+	}
+}
@@ -2,12 +2,21 @@ package kubeclient
 
 import (
 	"context"
-	"maps"
+	"errors"
+	"fmt"
 	"os"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -18,13 +27,66 @@ type KubeConfig struct {
 	ConfigFile string
 	Context    string
 	MasterUrl  string
+	// Contexts and the kubeconfig files in ConfigDir are collected in one run, see Clusters
+	Contexts  []string
+	ConfigDir string
+	// Input are files or directories to collect offline from instead of an API server, see NewOfflineClient
+	Input []string
+	// PageSize limits the number of namespaces and pods per list request, 0 lists all at once
+	PageSize int64
+	// Concurrency is the number of namespaces collected in parallel
+	Concurrency int
+	// QPS and Burst limit the requests to the API server, 0 uses the client-go defaults
+	QPS   float32
+	Burst int
+	// PreDeploy also collects the images declared in the pod templates of workloads, see ImageStateDeclared
+	PreDeploy bool
+
+	Scope
+}
+
+// Scope limits the namespaces and pods which are collected, the selectors are pushed down into the list requests
+type Scope struct {
+	// NamespaceSelector and PodSelector are label selectors, e.g. 'team=nazgul,env!=dev'
+	NamespaceSelector string
+	PodSelector       string
+	// Namespaces are fetched one by one instead of listing all namespaces, ExcludeNamespaces are never fetched
+	Namespaces        []string
+	ExcludeNamespaces []string
+}
+
+// Validate checks the syntax of the selectors
+func (s *Scope) Validate() error {
+	if _, err := labels.Parse(s.NamespaceSelector); err != nil {
+		return fmt.Errorf("invalid namespace selector: %w", err)
+	}
+	if _, err := labels.Parse(s.PodSelector); err != nil {
+		return fmt.Errorf("invalid pod selector: %w", err)
+	}
+	return nil
 }
 
 type Client struct {
-	Clientset kubernetes.Interface
+	Clientset   kubernetes.Interface
+	PageSize    int64
+	Concurrency int
+	PreDeploy   bool
+	Scope       Scope
 }
 
 func NewClient(cfg *KubeConfig) *Client {
+	if err := cfg.Scope.Validate(); err != nil {
+		log.Fatal().Stack().Err(err).Msg("Invalid scope")
+	}
+
+	if len(cfg.Input) > 0 {
+		client, err := NewOfflineClient(cfg.Input, cfg)
+		if err != nil {
+			log.Fatal().Stack().Err(err).Msg("Couldn't read offline input")
+		}
+		return client
+	}
+
 	kubeconfig := cfg.ConfigFile
 
 	if kubeconfig == "" {
@@ -49,7 +111,16 @@ func NewClient(cfg *KubeConfig) *Client {
 		log.Fatal().Stack().Err(err).Msg("Couldn't build config from flags")
 	}
 
-	client := &Client{Clientset: kubernetes.NewForConfigOrDie(config)}
+	config.QPS = cfg.QPS
+	config.Burst = cfg.Burst
+
+	client := &Client{
+		Clientset:   kubernetes.NewForConfigOrDie(config),
+		PageSize:    cfg.PageSize,
+		Concurrency: cfg.Concurrency,
+		PreDeploy:   cfg.PreDeploy,
+		Scope:       cfg.Scope,
+	}
 
 	return client
 
@@ -77,101 +148,519 @@ type Namespace struct {
 	Annotations map[string]string
 }
 
+// maxListRestarts limits how often a listing is restarted after its continue token expired
+const maxListRestarts = 3
+
+// listRestartBackoff is the wait before the first restart of a listing, doubled on every further restart
+var listRestartBackoff = time.Second
+
+// listPages calls list for every page of c.PageSize items until the last page.
+// If the continue token expired (410 Gone) the listing is restarted from the beginning after a backoff,
+// up to maxListRestarts times. list has to ignore items it has already seen.
+func (c *Client) listPages(opts metav1.ListOptions, list func(opts metav1.ListOptions) (metav1.ListInterface, error)) error {
+	opts.Limit = c.PageSize
+	restarts := 0
+	for {
+		result, err := list(opts)
+		if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+			if restarts == maxListRestarts {
+				return fmt.Errorf("listing restarted %d times as the continue token expired: %w", restarts, err)
+			}
+			backoff := listRestartBackoff << restarts
+			restarts++
+			log.Warn().Err(err).Int("restart", restarts).Dur("backoff", backoff).Msg("Continue token expired, restarting the listing")
+			time.Sleep(backoff)
+			opts.Continue = ""
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		opts.Continue = result.GetContinue()
+		if opts.Continue == "" {
+			return nil
+		}
+	}
+}
+
+// GetNamespaces returns the namespaces in the scope of the client. The namespaces are either listed by the
+// namespace selector, or fetched one by one if the scope contains a list of namespaces.
 func (c *Client) GetNamespaces() (*[]Namespace, error) {
-	k8Namespaces, err := c.Clientset.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+	if len(c.Scope.Namespaces) > 0 {
+		return c.getScopedNamespaces()
+	}
+
+	var namespaces []Namespace
+	seen := map[string]bool{}
+
+	opts := metav1.ListOptions{LabelSelector: c.Scope.NamespaceSelector, FieldSelector: c.excludeNamespacesSelector()}
+	err := c.listPages(opts, func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		k8Namespaces, err := c.Clientset.CoreV1().Namespaces().List(context.Background(), opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, k8Namespace := range k8Namespaces.Items {
+			if seen[k8Namespace.GetName()] || slices.Contains(c.Scope.ExcludeNamespaces, k8Namespace.GetName()) {
+				continue
+			}
+			seen[k8Namespace.GetName()] = true
+
+			namespace := Namespace{
+				Name:        k8Namespace.GetName(),
+				Labels:      k8Namespace.GetLabels(),
+				Annotations: k8Namespace.GetAnnotations(),
+			}
+			namespaces = append(namespaces, namespace)
+		}
+		return k8Namespaces, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &namespaces, nil
+}
+
+// excludeNamespacesSelector returns a field selector excluding the namespaces by name
+func (c *Client) excludeNamespacesSelector() string {
+	var selectors []fields.Selector
+	for _, name := range c.Scope.ExcludeNamespaces {
+		selectors = append(selectors, fields.OneTermNotEqualSelector("metadata.name", name))
+	}
+	return fields.AndSelectors(selectors...).String()
+}
+
+// getScopedNamespaces fetches the namespaces of the scope which are not excluded and match the namespace selector
+func (c *Client) getScopedNamespaces() (*[]Namespace, error) {
+	selector, err := labels.Parse(c.Scope.NamespaceSelector)
 	if err != nil {
 		return nil, err
 	}
+
 	var namespaces []Namespace
-	for _, k8Namespace := range k8Namespaces.Items {
-		namespace := Namespace{
-			Name:        k8Namespace.GetName(),
-			Labels:      k8Namespace.GetLabels(),
-			Annotations: k8Namespace.GetAnnotations(),
+	for _, name := range c.Scope.Namespaces {
+		if slices.Contains(c.Scope.ExcludeNamespaces, name) {
+			continue
+		}
+
+		namespace, err := c.GetNamespace(name)
+		if apierrors.IsForbidden(err) {
+			// Service accounts with a namespaced Role can't get their namespace, collect the pods without its metadata
+			if !selector.Empty() {
+				log.Warn().Err(err).Str("namespace", name).Msg("Namespace metadata is forbidden, skipping the namespace as the namespace selector can't be applied")
+				continue
+			}
+			log.Warn().Err(err).Str("namespace", name).Msg("Namespace metadata is forbidden, collecting without namespace labels and annotations")
+			namespace = &Namespace{Name: name}
+		} else if err != nil {
+			return nil, err
+		}
+		if !selector.Matches(labels.Set(namespace.Labels)) {
+			continue
 		}
-		namespaces = append(namespaces, namespace)
+		namespaces = append(namespaces, *namespace)
 	}
 	return &namespaces, nil
 }
 
+// GetNamespace returns the namespace with the given name
+func (c *Client) GetNamespace(name string) (*Namespace, error) {
+	k8Namespace, err := c.Clientset.CoreV1().Namespaces().Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &Namespace{
+		Name:        k8Namespace.GetName(),
+		Labels:      k8Namespace.GetLabels(),
+		Annotations: k8Namespace.GetAnnotations(),
+	}, nil
+}
+
+// GetConfigMapData returns the value of key in the given configmap
+func (c *Client) GetConfigMapData(namespace, name, key string) (string, error) {
+	configMap, err := c.Clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	data, ok := configMap.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in configmap %s/%s", key, namespace, name)
+	}
+	return data, nil
+}
+
+// ServerVersion returns the git version of the API server, e.g. v1.29.2, empty for offline input
+func (c *Client) ServerVersion() (string, error) {
+	info, err := c.Clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", err
+	}
+	return info.GitVersion, nil
+}
+
+// Image of a container. The labels and annotations of the pod, its workload and namespace are kept
+// separately and are never modified, their precedence is decided by the collector.
 type Image struct {
 	Image         string
 	ImageId       string
 	NamespaceName string
 	Labels        map[string]string
 	Annotations   map[string]string
+
+	NamespaceLabels      map[string]string
+	NamespaceAnnotations map[string]string
+	WorkloadLabels       map[string]string
+	WorkloadAnnotations  map[string]string
+
+	PodName       string
+	ContainerName string
+	ContainerRole string
+
+	WorkloadKind string
+	WorkloadName string
+	WorkloadUid  string
+
+	// Error is set instead of the pod and container fields if the pods of the namespace could not be listed,
+	// e.g. because it is forbidden
+	Error string
+
+	// State is running or declared in pre-deploy mode, empty otherwise. Declared images have no pod.
+	State string
 }
 
-// GetImages returns all images of all pods in the given namespaces
-// The Labels & Annotations of Pods and Namespaces are merged
-func (c *Client) GetImages(namespaces *[]Namespace) (*[]Image, error) {
+// Roles of a container within its pod
+const (
+	ContainerRoleApp       = "app"
+	ContainerRoleInit      = "init"
+	ContainerRoleSidecar   = "sidecar"
+	ContainerRoleEphemeral = "ephemeral"
+)
+
+type container struct {
+	name  string
+	image string
+	role  string
+}
+
+// getContainerImages returns the images of all app, init and ephemeral containers of the pod.
+// Init containers with restartPolicy Always are native sidecars.
+func getContainerImages(pod *corev1.Pod) []Image {
+	var containers []container
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, container{name: c.Name, image: c.Image, role: ContainerRoleApp})
+	}
+	var initContainers []container
+	for _, c := range pod.Spec.InitContainers {
+		role := ContainerRoleInit
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			role = ContainerRoleSidecar
+		}
+		initContainers = append(initContainers, container{name: c.Name, image: c.Image, role: role})
+	}
+	var ephemeralContainers []container
+	for _, c := range pod.Spec.EphemeralContainers {
+		ephemeralContainers = append(ephemeralContainers, container{name: c.Name, image: c.Image, role: ContainerRoleEphemeral})
+	}
+
 	var images []Image
+	images = append(images, matchContainerStatuses(containers, pod.Status.ContainerStatuses, ContainerRoleApp)...)
+	images = append(images, matchContainerStatuses(initContainers, pod.Status.InitContainerStatuses, ContainerRoleInit)...)
+	images = append(images, matchContainerStatuses(ephemeralContainers, pod.Status.EphemeralContainerStatuses, ContainerRoleEphemeral)...)
+	return images
+}
 
-	for _, namespace := range *namespaces {
-		pods, err := c.Clientset.CoreV1().Pods(namespace.Name).List(context.Background(), metav1.ListOptions{})
-		if err != nil {
-			return nil, err
+// matchContainerStatuses creates an image for every container with a status, taking the image id from the status,
+// and for all remaining containers for which no status exists yet.
+// statusRole is used for statuses without a matching container.
+func matchContainerStatuses(containers []container, statuses []corev1.ContainerStatus, statusRole string) []Image {
+	var images []Image
+
+	containerByName := map[string]container{}
+	for _, c := range containers {
+		containerByName[c.name] = c
+	}
+
+	// Create images for all containers with status
+	for _, status := range statuses {
+		c, ok := containerByName[status.Name]
+		delete(containerByName, status.Name)
+		if !ok {
+			c = container{name: status.Name, role: statusRole}
 		}
 
-		for _, pod := range pods.Items {
+		// Don't create an image if no image name exists
+		if c.image == "" && status.Image == "" {
+			continue
+		} else if c.image == "" {
+			c.image = status.Image
+		}
 
-			// Merge Pod and Namespace Labels & Annotations
-			labels := pod.GetLabels()
-			if labels == nil {
-				labels = namespace.Labels
-			} else {
-				maps.Copy(labels, namespace.Labels)
-			}
-			annotations := pod.GetAnnotations()
-			if annotations == nil {
-				annotations = namespace.Annotations
-			} else {
-				maps.Copy(annotations, namespace.Annotations)
-			}
+		images = append(images, Image{
+			Image:         c.image,
+			ImageId:       status.ImageID,
+			ContainerName: c.name,
+			ContainerRole: c.role,
+		})
+	}
+
+	// Add all remaining container images for which no status exists
+	for _, c := range containers {
+		if _, ok := containerByName[c.name]; !ok || c.image == "" {
+			continue
+		}
+		images = append(images, Image{
+			Image:         c.image,
+			ContainerName: c.name,
+			ContainerRole: c.role,
+		})
+	}
+
+	return images
+}
+
+// Workload is the top-level controller owning a pod, e.g. a Deployment or CronJob.
+// Pods without a controller are their own workload.
+type Workload struct {
+	Kind        string
+	Name        string
+	Uid         string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// errUnsupportedKind is returned for owners which are not built-in workload kinds, e.g. custom resources
+var errUnsupportedKind = errors.New("unsupported owner kind")
+
+// getOwner returns the built-in workload object of the given kind
+func (c *Client) getOwner(namespace, kind, name string) (metav1.Object, error) {
+	ctx := context.Background()
+	switch kind {
+	case "ReplicaSet":
+		return c.Clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "Deployment":
+		return c.Clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "StatefulSet":
+		return c.Clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "DaemonSet":
+		return c.Clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "Job":
+		return c.Clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "CronJob":
+		return c.Clientset.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	return nil, errUnsupportedKind
+}
+
+// getWorkload follows the controller owner references of the pod to its top-level workload
+// (Pod -> ReplicaSet -> Deployment, Pod -> Job -> CronJob, Pod -> StatefulSet, ...).
+// Resolved owners are cached by UID, as many pods usually share the same owner, the cache must not be shared between goroutines.
+func (c *Client) getWorkload(pod *corev1.Pod, cache map[types.UID]Workload) Workload {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return Workload{Kind: "Pod", Name: pod.GetName(), Uid: string(pod.GetUID())}
+	}
+
+	if workload, ok := cache[owner.UID]; ok {
+		return workload
+	}
+
+	workload := Workload{Kind: owner.Kind, Name: owner.Name, Uid: string(owner.UID)}
 
-			// Get all container images
-			containerImageMap := map[string]string{}
-			for _, container := range pod.Spec.Containers {
-				containerImageMap[container.Name] = container.Image
+	for ref := owner; ref != nil; {
+		object, err := c.getOwner(pod.GetNamespace(), ref.Kind, ref.Name)
+		if errors.Is(err, errUnsupportedKind) {
+			log.Debug().Str("namespace", pod.GetNamespace()).Str("kind", ref.Kind).Str("name", ref.Name).Msg("Owner is not a built-in workload, using it as workload")
+			break
+		} else if err != nil {
+			log.Warn().Err(err).Str("namespace", pod.GetNamespace()).Str("kind", ref.Kind).Str("name", ref.Name).Msg("Could not resolve owner, using it as workload")
+			break
+		}
+
+		workload = Workload{
+			Kind:        ref.Kind,
+			Name:        ref.Name,
+			Uid:         string(ref.UID),
+			Labels:      object.GetLabels(),
+			Annotations: object.GetAnnotations(),
+		}
+		ref = metav1.GetControllerOf(object)
+		if ref != nil {
+			workload = Workload{Kind: ref.Kind, Name: ref.Name, Uid: string(ref.UID)}
+		}
+	}
+
+	cache[owner.UID] = workload
+	return workload
+}
+
+// errStopped stops listing the pods of a namespace after ForEachImage returned
+var errStopped = errors.New("stopped collecting images")
+
+// ForEachImage calls fn for every image of all (init, sidecar, ephemeral) containers of all pods in the given namespaces.
+// Namespaces in which listing pods is forbidden are passed as a single image with the Error set, instead of aborting.
+// Up to c.Concurrency namespaces are collected in parallel, fn is called from a single goroutine
+// with the images in the order of the namespaces and pods, independent of the concurrency.
+// A namespace keeps its slot until fn was called for all its images, and its pods are listed page by page
+// while fn consumes them, so at most a page of pods of c.Concurrency namespaces is held in memory at once.
+// The Labels & Annotations of Pods, their Workloads and Namespaces are kept separately, see Image
+func (c *Client) ForEachImage(namespaces *[]Namespace, fn func(Image) error) error {
+	type page struct {
+		images []Image
+		err    error
+	}
+
+	// The pages of every namespace, closed after its last page
+	pages := make([]chan page, len(*namespaces))
+	for i := range pages {
+		pages[i] = make(chan page)
+	}
+
+	// Stop scheduling namespaces on return and wait for the running ones
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	window := make(chan struct{}, max(c.Concurrency, 1))
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, namespace := range *namespaces {
+			select {
+			case window <- struct{}{}:
+			case <-done:
+				return
 			}
+			wg.Add(1)
+			go func(i int, namespace Namespace) {
+				defer wg.Done()
+				defer close(pages[i])
 
-			// Create images for all containers with status
-			for _, status := range pod.Status.ContainerStatuses {
-				var imageName string
-				containerImage := containerImageMap[status.Name]
-				delete(containerImageMap, status.Name)
-
-				// Don't create an image if no image name exists
-				if containerImage == "" && status.Image == "" {
-					continue
-				} else if containerImage == "" {
-					imageName = status.Image
-				} else {
-					imageName = containerImage
+				send := func(p page) error {
+					select {
+					case pages[i] <- p:
+						return nil
+					case <-done:
+						return errStopped
+					}
 				}
 
-				image := Image{
-					Image:         imageName,
-					ImageId:       status.ImageID,
-					NamespaceName: namespace.Name,
-					Labels:        labels,
-					Annotations:   annotations,
+				err := c.listNamespaceImages(namespace, func(images []Image) error {
+					return send(page{images: images})
+				})
+				if apierrors.IsForbidden(err) {
+					log.Warn().Err(err).Str("namespace", namespace.Name).Msg("Listing pods is forbidden, reporting the namespace as erroneous")
+					err = send(page{images: []Image{{
+						NamespaceName:        namespace.Name,
+						NamespaceLabels:      namespace.Labels,
+						NamespaceAnnotations: namespace.Annotations,
+						Error:                err.Error(),
+					}}})
+				}
+				if err != nil && !errors.Is(err, errStopped) {
+					_ = send(page{err: err})
+				}
+			}(i, namespace)
+		}
+	}()
+
+	for i := range *namespaces {
+		for p := range pages[i] {
+			if p.err != nil {
+				return p.err
+			}
+			for _, image := range p.images {
+				if err := fn(image); err != nil {
+					return err
 				}
-				images = append(images, image)
 			}
+		}
+		// All images of the namespace are consumed, the next one may be collected
+		<-window
+	}
 
-			// Add all remaining container images for which no status exists
-			for _, imageName := range containerImageMap {
+	return nil
+}
 
-				image := Image{
-					Image:         imageName,
-					NamespaceName: namespace.Name,
-					Labels:        labels,
-					Annotations:   annotations,
-				}
-				images = append(images, image)
+// listNamespaceImages calls page with the images of every page of pods in the namespace,
+// in pre-deploy mode followed by the declared images
+func (c *Client) listNamespaceImages(namespace Namespace, page func([]Image) error) error {
+	workloads := map[types.UID]Workload{}
+	seen := map[string]bool{}
+	running := map[runningKey]bool{}
+
+	opts := metav1.ListOptions{LabelSelector: c.Scope.PodSelector}
+	err := c.listPages(opts, func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		pods, err := c.Clientset.CoreV1().Pods(namespace.Name).List(context.Background(), opts)
+		if err != nil {
+			return nil, err
+		}
+
+		var images []Image
+		for _, pod := range pods.Items {
+			if seen[pod.GetName()] {
+				continue
+			}
+			seen[pod.GetName()] = true
+
+			images = append(images, c.getPodImages(namespace, &pod, workloads)...)
+		}
+		if c.PreDeploy {
+			for i := range images {
+				images[i].State = ImageStateRunning
+				running[newRunningKey(&images[i])] = true
+			}
+		}
+		if len(images) > 0 {
+			if err = page(images); err != nil {
+				return nil, err
 			}
 		}
+		return pods, nil
+	})
+	if err != nil || !c.PreDeploy {
+		return err
+	}
+
+	declared, err := c.getDeclaredImages(namespace, running)
+	if err != nil || len(declared) == 0 {
+		return err
+	}
+	return page(declared)
+}
+
+// getPodImages returns the images of the containers of the pod with the metadata of the pod, its workload and namespace
+func (c *Client) getPodImages(namespace Namespace, pod *corev1.Pod, workloads map[types.UID]Workload) []Image {
+	workload := c.getWorkload(pod, workloads)
+
+	images := getContainerImages(pod)
+	for i := range images {
+		images[i].NamespaceName = namespace.Name
+		images[i].PodName = pod.GetName()
+		images[i].Labels = pod.GetLabels()
+		images[i].Annotations = pod.GetAnnotations()
+		images[i].NamespaceLabels = namespace.Labels
+		images[i].NamespaceAnnotations = namespace.Annotations
+		images[i].WorkloadKind = workload.Kind
+		images[i].WorkloadName = workload.Name
+		images[i].WorkloadUid = workload.Uid
+		images[i].WorkloadLabels = workload.Labels
+		images[i].WorkloadAnnotations = workload.Annotations
+	}
+	return images
+}
+
+// GetImages returns all images of all (init, sidecar, ephemeral) containers of all pods in the given namespaces, see ForEachImage
+func (c *Client) GetImages(namespaces *[]Namespace) (*[]Image, error) {
+	var images []Image
+
+	err := c.ForEachImage(namespaces, func(image Image) error {
+		images = append(images, image)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &images, nil
@@ -1,13 +1,25 @@
 package kubeclient
 
 import (
+	"errors"
+	"fmt"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestGetNamespaces(t *testing.T) {
@@ -272,11 +284,13 @@ func TestGetImages(t *testing.T) {
 			},
 			expectedImages: []Image{
 				Image{
-					Image:         "quay.io/test/test:latest",
-					ImageId:       "",
-					NamespaceName: "test_ns_1",
-					Labels:        map[string]string{"pod_label_1": "value_1", "label_a": "val_a"},
-					Annotations:   map[string]string{"ann_a": "val_a"},
+					Image:                "quay.io/test/test:latest",
+					ImageId:              "",
+					NamespaceName:        "test_ns_1",
+					Labels:               map[string]string{"pod_label_1": "value_1"},
+					Annotations:          nil,
+					NamespaceLabels:      map[string]string{"label_a": "val_a"},
+					NamespaceAnnotations: map[string]string{"ann_a": "val_a"},
 				},
 			},
 			expectSuccess: true,
@@ -364,11 +378,13 @@ func TestGetImages(t *testing.T) {
 			},
 			expectedImages: []Image{
 				Image{
-					Image:         "quay.io/test/test:latest",
-					ImageId:       "",
-					NamespaceName: "test_ns_1",
-					Labels:        map[string]string{"pod_label_1": "value_1", "label_a": "val_a"},
-					Annotations:   map[string]string{"ann_a": "val_a"},
+					Image:                "quay.io/test/test:latest",
+					ImageId:              "",
+					NamespaceName:        "test_ns_1",
+					Labels:               map[string]string{"pod_label_1": "value_1"},
+					Annotations:          nil,
+					NamespaceLabels:      map[string]string{"label_a": "val_a"},
+					NamespaceAnnotations: map[string]string{"ann_a": "val_a"},
 				},
 			},
 			expectSuccess: true,
@@ -456,18 +472,22 @@ func TestGetImages(t *testing.T) {
 			},
 			expectedImages: []Image{
 				Image{
-					Image:         "quay.io/test/test:v2",
-					ImageId:       "",
-					NamespaceName: "test_ns_2",
-					Labels:        map[string]string{"pod_label_1": "value_1", "label_c": "val_c", "label_d": "val_d"},
-					Annotations:   map[string]string{"ann_c": "val_c", "ann_d": "val_d"},
+					Image:                "quay.io/test/test:v2",
+					ImageId:              "",
+					NamespaceName:        "test_ns_2",
+					Labels:               map[string]string{"pod_label_1": "value_1"},
+					Annotations:          nil,
+					NamespaceLabels:      map[string]string{"label_c": "val_c", "label_d": "val_d"},
+					NamespaceAnnotations: map[string]string{"ann_c": "val_c", "ann_d": "val_d"},
 				},
 				Image{
-					Image:         "quay.io/test/test:v3",
-					ImageId:       "",
-					NamespaceName: "test_ns_2",
-					Labels:        map[string]string{"pod_label_1": "value_1", "label_c": "val_c", "label_d": "val_d"},
-					Annotations:   map[string]string{"ann_c": "val_c", "ann_d": "val_d"},
+					Image:                "quay.io/test/test:v3",
+					ImageId:              "",
+					NamespaceName:        "test_ns_2",
+					Labels:               map[string]string{"pod_label_1": "value_1"},
+					Annotations:          nil,
+					NamespaceLabels:      map[string]string{"label_c": "val_c", "label_d": "val_d"},
+					NamespaceAnnotations: map[string]string{"ann_c": "val_c", "ann_d": "val_d"},
 				},
 			},
 			expectSuccess: true,
@@ -510,21 +530,30 @@ func TestGetImages(t *testing.T) {
 					t.Fatalf("Expected namespace %s but got %s\n", expectedImg.NamespaceName, img.NamespaceName)
 				}
 
-				for label, value := range expectedImg.Labels {
-					labelValue, ok := img.Labels[label]
+				// Pod labels and annotations must not be merged with the namespace ones
+				if !reflect.DeepEqual(expectedImg.Labels, img.Labels) {
+					t.Fatalf("Expected labels %v but got %v\n", expectedImg.Labels, img.Labels)
+				}
+
+				if !reflect.DeepEqual(expectedImg.Annotations, img.Annotations) {
+					t.Fatalf("Expected annotations %v but got %v\n", expectedImg.Annotations, img.Annotations)
+				}
+
+				for label, value := range expectedImg.NamespaceLabels {
+					labelValue, ok := img.NamespaceLabels[label]
 					if !ok {
-						t.Fatalf("Expected label %s but got none\n", label)
+						t.Fatalf("Expected namespace label %s but got none\n", label)
 					} else if labelValue != value {
-						t.Fatalf("Expected label %s with value %s but got %s\n", label, value, labelValue)
+						t.Fatalf("Expected namespace label %s with value %s but got %s\n", label, value, labelValue)
 					}
 				}
 
-				for annotation, value := range expectedImg.Annotations {
-					annotationValue, ok := img.Annotations[annotation]
+				for annotation, value := range expectedImg.NamespaceAnnotations {
+					annotationValue, ok := img.NamespaceAnnotations[annotation]
 					if !ok {
-						t.Fatalf("Expected annotation %s but got none\n", annotation)
+						t.Fatalf("Expected namespace annotation %s but got none\n", annotation)
 					} else if annotationValue != value {
-						t.Fatalf("Expected annotation %s with value %s but got %s\n", annotation, value, annotationValue)
+						t.Fatalf("Expected namespace annotation %s with value %s but got %s\n", annotation, value, annotationValue)
 					}
 				}
 			}
@@ -615,11 +644,13 @@ func TestGetAllImages(t *testing.T) {
 			},
 			expectedImages: []Image{
 				Image{
-					Image:         "quay.io/test/test:latest",
-					ImageId:       "",
-					NamespaceName: "test_ns_1",
-					Labels:        map[string]string{"pod_label_1": "value_1", "label_a": "val_a"},
-					Annotations:   map[string]string{"ann_a": "val_a"},
+					Image:                "quay.io/test/test:latest",
+					ImageId:              "",
+					NamespaceName:        "test_ns_1",
+					Labels:               map[string]string{"pod_label_1": "value_1"},
+					Annotations:          nil,
+					NamespaceLabels:      map[string]string{"label_a": "val_a"},
+					NamespaceAnnotations: map[string]string{"ann_a": "val_a"},
 				},
 			},
 			expectSuccess: true,
@@ -700,25 +731,31 @@ func TestGetAllImages(t *testing.T) {
 			},
 			expectedImages: []Image{
 				Image{
-					Image:         "quay.io/test/test:latest",
-					ImageId:       "",
-					NamespaceName: "test_ns_1",
-					Labels:        map[string]string{"pod_label_1": "value_1", "label_a": "val_a"},
-					Annotations:   map[string]string{"ann_a": "val_a"},
+					Image:                "quay.io/test/test:latest",
+					ImageId:              "",
+					NamespaceName:        "test_ns_1",
+					Labels:               map[string]string{"pod_label_1": "value_1"},
+					Annotations:          nil,
+					NamespaceLabels:      map[string]string{"label_a": "val_a"},
+					NamespaceAnnotations: map[string]string{"ann_a": "val_a"},
 				},
 				Image{
-					Image:         "quay.io/test/test:v2",
-					ImageId:       "",
-					NamespaceName: "test_ns_2",
-					Labels:        map[string]string{"pod_label_1": "value_1", "label_c": "val_c", "label_d": "val_d"},
-					Annotations:   map[string]string{"ann_c": "val_c", "ann_d": "val_d"},
+					Image:                "quay.io/test/test:v2",
+					ImageId:              "",
+					NamespaceName:        "test_ns_2",
+					Labels:               map[string]string{"pod_label_1": "value_1"},
+					Annotations:          nil,
+					NamespaceLabels:      map[string]string{"label_c": "val_c", "label_d": "val_d"},
+					NamespaceAnnotations: map[string]string{"ann_c": "val_c", "ann_d": "val_d"},
 				},
 				Image{
-					Image:         "quay.io/test/test:3",
-					ImageId:       "",
-					NamespaceName: "test_ns_2",
-					Labels:        map[string]string{"pod_label_1": "value_1", "label_c": "val_c", "label_d": "val_d"},
-					Annotations:   map[string]string{"ann_c": "val_c", "ann_d": "val_d"},
+					Image:                "quay.io/test/test:3",
+					ImageId:              "",
+					NamespaceName:        "test_ns_2",
+					Labels:               map[string]string{"pod_label_1": "value_1"},
+					Annotations:          nil,
+					NamespaceLabels:      map[string]string{"label_c": "val_c", "label_d": "val_d"},
+					NamespaceAnnotations: map[string]string{"ann_c": "val_c", "ann_d": "val_d"},
 				}},
 			expectSuccess: true,
 		},
@@ -798,25 +835,31 @@ func TestGetAllImages(t *testing.T) {
 			},
 			expectedImages: []Image{
 				Image{
-					Image:         "quay.io/test/test:latest",
-					ImageId:       "",
-					NamespaceName: "test_ns_1",
-					Labels:        map[string]string{"label_a": "val_a", "pod_label_1": "value_1"},
-					Annotations:   map[string]string{"ann_a": "val_a"},
+					Image:                "quay.io/test/test:latest",
+					ImageId:              "",
+					NamespaceName:        "test_ns_1",
+					Labels:               map[string]string{"pod_label_1": "value_1"},
+					Annotations:          nil,
+					NamespaceLabels:      map[string]string{"label_a": "val_a"},
+					NamespaceAnnotations: map[string]string{"ann_a": "val_a"},
 				},
 				Image{
-					Image:         "quay.io/test/test:v2",
-					ImageId:       "",
-					NamespaceName: "test_ns_2",
-					Labels:        map[string]string{"pod_label_1": "value_1", "label_c": "val_c", "label_d": "val_d"},
-					Annotations:   map[string]string{"ann_c": "val_c", "ann_d": "val_d"},
+					Image:                "quay.io/test/test:v2",
+					ImageId:              "",
+					NamespaceName:        "test_ns_2",
+					Labels:               map[string]string{"pod_label_1": "value_1"},
+					Annotations:          nil,
+					NamespaceLabels:      map[string]string{"label_c": "val_c", "label_d": "val_d"},
+					NamespaceAnnotations: map[string]string{"ann_c": "val_c", "ann_d": "val_d"},
 				},
 				Image{
-					Image:         "quay.io/test/test:v3",
-					ImageId:       "",
-					NamespaceName: "test_ns_2",
-					Labels:        map[string]string{"pod_label_1": "value_1", "label_c": "val_c", "label_d": "val_d"},
-					Annotations:   map[string]string{"ann_c": "val_c", "ann_d": "val_d"},
+					Image:                "quay.io/test/test:v3",
+					ImageId:              "",
+					NamespaceName:        "test_ns_2",
+					Labels:               map[string]string{"pod_label_1": "value_1"},
+					Annotations:          nil,
+					NamespaceLabels:      map[string]string{"label_c": "val_c", "label_d": "val_d"},
+					NamespaceAnnotations: map[string]string{"ann_c": "val_c", "ann_d": "val_d"},
 				},
 			},
 			expectSuccess: true,
@@ -859,21 +902,30 @@ func TestGetAllImages(t *testing.T) {
 					t.Fatalf("Expected namespace %s but got %s\n", expectedImg.NamespaceName, img.NamespaceName)
 				}
 
-				for label, value := range expectedImg.Labels {
-					labelValue, ok := img.Labels[label]
+				// Pod labels and annotations must not be merged with the namespace ones
+				if !reflect.DeepEqual(expectedImg.Labels, img.Labels) {
+					t.Fatalf("Expected labels %v but got %v\n", expectedImg.Labels, img.Labels)
+				}
+
+				if !reflect.DeepEqual(expectedImg.Annotations, img.Annotations) {
+					t.Fatalf("Expected annotations %v but got %v\n", expectedImg.Annotations, img.Annotations)
+				}
+
+				for label, value := range expectedImg.NamespaceLabels {
+					labelValue, ok := img.NamespaceLabels[label]
 					if !ok {
-						t.Fatalf("Expected label %s but got none\n", label)
+						t.Fatalf("Expected namespace label %s but got none\n", label)
 					} else if labelValue != value {
-						t.Fatalf("Expected label %s with value %s but got %s\n", label, value, labelValue)
+						t.Fatalf("Expected namespace label %s with value %s but got %s\n", label, value, labelValue)
 					}
 				}
 
-				for annotation, value := range expectedImg.Annotations {
-					annotationValue, ok := img.Annotations[annotation]
+				for annotation, value := range expectedImg.NamespaceAnnotations {
+					annotationValue, ok := img.NamespaceAnnotations[annotation]
 					if !ok {
-						t.Fatalf("Expected annotation %s but got none\n", annotation)
+						t.Fatalf("Expected namespace annotation %s but got none\n", annotation)
 					} else if annotationValue != value {
-						t.Fatalf("Expected annotation %s with value %s but got %s\n", annotation, value, annotationValue)
+						t.Fatalf("Expected namespace annotation %s with value %s but got %s\n", annotation, value, annotationValue)
 					}
 				}
 			}
@@ -881,3 +933,540 @@ func TestGetAllImages(t *testing.T) {
 		})
 	}
 }
+
+func controllerRef(kind, name, uid string) []metav1.OwnerReference {
+	isController := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, UID: types.UID(uid), Controller: &isController}}
+}
+
+func TestGetImagesWorkload(t *testing.T) {
+	var client Client
+
+	objects := []runtime.Object{
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "deploy", Namespace: "test_ns_1", UID: "deploy-uid", Labels: map[string]string{"workload_label": "deploy"}},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "deploy-rs", Namespace: "test_ns_1", UID: "rs-uid", OwnerReferences: controllerRef("Deployment", "deploy", "deploy-uid")},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "orphan-rs", Namespace: "test_ns_1", UID: "orphan-rs-uid", Labels: map[string]string{"workload_label": "orphan"}},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "cron-123", Namespace: "test_ns_1", UID: "job-uid", OwnerReferences: controllerRef("CronJob", "cron", "cron-uid")},
+		},
+	}
+
+	testCases := []struct {
+		name             string
+		ownerReferences  []metav1.OwnerReference
+		expectedWorkload Workload
+	}{
+		{
+			name:             "PodWithoutOwnerIsItsOwnWorkload",
+			ownerReferences:  nil,
+			expectedWorkload: Workload{Kind: "Pod", Name: "pod1", Uid: "pod-uid"},
+		},
+		{
+			name:             "ReplicaSetResolvesToDeployment",
+			ownerReferences:  controllerRef("ReplicaSet", "deploy-rs", "rs-uid"),
+			expectedWorkload: Workload{Kind: "Deployment", Name: "deploy", Uid: "deploy-uid", Labels: map[string]string{"workload_label": "deploy"}},
+		},
+		{
+			name:             "ReplicaSetWithoutOwner",
+			ownerReferences:  controllerRef("ReplicaSet", "orphan-rs", "orphan-rs-uid"),
+			expectedWorkload: Workload{Kind: "ReplicaSet", Name: "orphan-rs", Uid: "orphan-rs-uid", Labels: map[string]string{"workload_label": "orphan"}},
+		},
+		{
+			name:             "JobResolvesToMissingCronJob",
+			ownerReferences:  controllerRef("Job", "cron-123", "job-uid"),
+			expectedWorkload: Workload{Kind: "CronJob", Name: "cron", Uid: "cron-uid"},
+		},
+		{
+			name:             "MissingStatefulSetIsTopLevel",
+			ownerReferences:  controllerRef("StatefulSet", "sts", "sts-uid"),
+			expectedWorkload: Workload{Kind: "StatefulSet", Name: "sts", Uid: "sts-uid"},
+		},
+		{
+			name:             "CustomResourceIsTopLevel",
+			ownerReferences:  controllerRef("Rollout", "rollout", "rollout-uid"),
+			expectedWorkload: Workload{Kind: "Rollout", Name: "rollout", Uid: "rollout-uid"},
+		},
+		{
+			name:             "MissingReplicaSetFallsBackToOwner",
+			ownerReferences:  controllerRef("ReplicaSet", "gone-rs", "gone-rs-uid"),
+			expectedWorkload: Workload{Kind: "ReplicaSet", Name: "gone-rs", Uid: "gone-rs-uid"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "pod1",
+					Namespace:       "test_ns_1",
+					UID:             "pod-uid",
+					OwnerReferences: tc.ownerReferences,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "container1", Image: "quay.io/test/test:latest"}},
+				},
+			}
+			client.Clientset = testclient.NewSimpleClientset(append(objects, pod)...)
+			images, err := client.GetImages(&[]Namespace{{Name: "test_ns_1"}})
+
+			if err != nil {
+				t.Fatalf("Got an error=%v\n", err)
+			} else if len(*images) != 1 {
+				t.Fatalf("Expected 1 image but got %d\n", len(*images))
+			}
+
+			img := (*images)[0]
+			workload := Workload{Kind: img.WorkloadKind, Name: img.WorkloadName, Uid: img.WorkloadUid, Labels: img.WorkloadLabels}
+			if !reflect.DeepEqual(workload, tc.expectedWorkload) {
+				t.Fatalf("Expected workload %v but got %v\n", tc.expectedWorkload, workload)
+			}
+		})
+	}
+}
+
+func TestGetImagesContainerRoles(t *testing.T) {
+	var client Client
+	restartAlways := corev1.ContainerRestartPolicyAlways
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "test_ns_1",
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "app", Image: "quay.io/test/app:1"},
+			},
+			InitContainers: []corev1.Container{
+				{Name: "migrate", Image: "quay.io/test/migrate:1"},
+				{Name: "proxy", Image: "quay.io/test/proxy:1", RestartPolicy: &restartAlways},
+			},
+			EphemeralContainers: []corev1.EphemeralContainer{
+				{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox"}},
+			},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", Image: "quay.io/test/app:1", ImageID: "quay.io/test/app@sha256:app"},
+			},
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "migrate", Image: "quay.io/test/migrate:1", ImageID: "quay.io/test/migrate@sha256:migrate"},
+			},
+			EphemeralContainerStatuses: []corev1.ContainerStatus{
+				{Name: "debugger", Image: "docker.io/library/busybox:latest", ImageID: "docker.io/library/busybox@sha256:busybox"},
+			},
+		},
+	}
+
+	expectedImages := []Image{
+		{Image: "busybox", ImageId: "docker.io/library/busybox@sha256:busybox", ContainerName: "debugger", ContainerRole: ContainerRoleEphemeral},
+		{Image: "quay.io/test/app:1", ImageId: "quay.io/test/app@sha256:app", ContainerName: "app", ContainerRole: ContainerRoleApp},
+		{Image: "quay.io/test/migrate:1", ImageId: "quay.io/test/migrate@sha256:migrate", ContainerName: "migrate", ContainerRole: ContainerRoleInit},
+		{Image: "quay.io/test/proxy:1", ImageId: "", ContainerName: "proxy", ContainerRole: ContainerRoleSidecar},
+	}
+
+	client.Clientset = testclient.NewSimpleClientset(pod)
+	images, err := client.GetImages(&[]Namespace{{Name: "test_ns_1"}})
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	} else if len(*images) != len(expectedImages) {
+		t.Fatalf("Expected %d images but got %d, (images=%v)\n", len(expectedImages), len(*images), *images)
+	}
+
+	sort.Slice(*images, func(i, j int) bool {
+		return strings.ToLower((*images)[i].Image) < strings.ToLower((*images)[j].Image)
+	})
+
+	for idx, img := range *images {
+		expectedImg := expectedImages[idx]
+		if expectedImg.Image != img.Image || expectedImg.ImageId != img.ImageId {
+			t.Fatalf("Expected image %s (%s) but got %s (%s)\n", expectedImg.Image, expectedImg.ImageId, img.Image, img.ImageId)
+		}
+		if img.PodName != "pod1" {
+			t.Fatalf("Expected pod pod1 but got %s\n", img.PodName)
+		}
+		if expectedImg.ContainerName != img.ContainerName || expectedImg.ContainerRole != img.ContainerRole {
+			t.Fatalf("Expected container %s (%s) but got %s (%s)\n", expectedImg.ContainerName, expectedImg.ContainerRole, img.ContainerName, img.ContainerRole)
+		}
+	}
+}
+
+func TestGetNamespace(t *testing.T) {
+	var client Client
+	client.Clientset = testclient.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test_ns_1",
+			Labels:      map[string]string{"label_a": "val_a"},
+			Annotations: map[string]string{"ann_a": "val_a"},
+		},
+	})
+
+	namespace, err := client.GetNamespace("test_ns_1")
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+	expectedNamespace := Namespace{
+		Name:        "test_ns_1",
+		Labels:      map[string]string{"label_a": "val_a"},
+		Annotations: map[string]string{"ann_a": "val_a"},
+	}
+	if !reflect.DeepEqual(*namespace, expectedNamespace) {
+		t.Fatalf("Expected namespace %v but got %v\n", expectedNamespace, *namespace)
+	}
+
+	if _, err = client.GetNamespace("does-not-exist"); err == nil {
+		t.Fatalf("Expected an error but got none\n")
+	}
+}
+
+func TestListPages(t *testing.T) {
+	listRestartBackoff = time.Millisecond
+	defer func() { listRestartBackoff = time.Second }()
+
+	client := Client{PageSize: 2}
+	items := []string{"a", "b", "c", "d", "e"}
+
+	var requests []metav1.ListOptions
+	var listed []string
+	expired := false
+
+	err := client.listPages(metav1.ListOptions{}, func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		requests = append(requests, opts)
+
+		// The first continue token expires once
+		if opts.Continue == "2" && !expired {
+			expired = true
+			return nil, apierrors.NewResourceExpired("continue token expired")
+		}
+
+		start := 0
+		if opts.Continue != "" {
+			start, _ = strconv.Atoi(opts.Continue)
+		}
+		end := min(start+int(opts.Limit), len(items))
+		listed = append(listed, items[start:end]...)
+
+		list := &metav1.List{}
+		if end < len(items) {
+			list.Continue = strconv.Itoa(end)
+		}
+		return list, nil
+	})
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+
+	expectedContinues := []string{"", "2", "", "2", "4"}
+	var continues []string
+	for _, request := range requests {
+		if request.Limit != 2 {
+			t.Fatalf("Expected limit 2 but got %d\n", request.Limit)
+		}
+		continues = append(continues, request.Continue)
+	}
+	if !reflect.DeepEqual(continues, expectedContinues) {
+		t.Fatalf("Expected continue tokens %v but got %v\n", expectedContinues, continues)
+	}
+
+	// Items of the restarted listing are listed again, the caller skips already seen items
+	expectedListed := []string{"a", "b", "a", "b", "c", "d", "e"}
+	if !reflect.DeepEqual(listed, expectedListed) {
+		t.Fatalf("Expected listed items %v but got %v\n", expectedListed, listed)
+	}
+
+	err = client.listPages(metav1.ListOptions{}, func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		return nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("forbidden"))
+	})
+	if !apierrors.IsForbidden(err) {
+		t.Fatalf("Expected forbidden error but got %v\n", err)
+	}
+}
+
+func TestForEachImageStopsOnError(t *testing.T) {
+	var client Client
+	client.Clientset = testclient.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod_1", Namespace: "test_ns_1"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "a", Image: "image_a"}, {Name: "b", Image: "image_b"}}},
+		},
+	)
+
+	calls := 0
+	stop := errors.New("stop")
+	err := client.ForEachImage(&[]Namespace{{Name: "test_ns_1"}}, func(image Image) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Fatalf("Expected the error of the first call but got %v after %d calls\n", err, calls)
+	}
+}
+
+func TestListPagesRestartLimit(t *testing.T) {
+	listRestartBackoff = time.Millisecond
+	defer func() { listRestartBackoff = time.Second }()
+
+	client := Client{PageSize: 2}
+	calls := 0
+	err := client.listPages(metav1.ListOptions{}, func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		calls++
+		// The continue token always expires, e.g. in a namespace changing faster than it is listed
+		if opts.Continue != "" {
+			return nil, apierrors.NewResourceExpired("continue token expired")
+		}
+		return &metav1.List{ListMeta: metav1.ListMeta{Continue: "2"}}, nil
+	})
+	if !apierrors.IsResourceExpired(err) {
+		t.Fatalf("Expected the expired error but got %v\n", err)
+	}
+	if expected := 2 * (maxListRestarts + 1); calls != expected {
+		t.Fatalf("Expected %d list calls but got %d\n", expected, calls)
+	}
+}
+
+func TestForEachImageConcurrentOrder(t *testing.T) {
+	var objects []runtime.Object
+	var namespaces []Namespace
+	var expectedOrder []string
+	for i := 0; i < 20; i++ {
+		namespace := fmt.Sprintf("test_ns_%02d", i)
+		namespaces = append(namespaces, Namespace{Name: namespace})
+		for j := 0; j < 3; j++ {
+			pod := fmt.Sprintf("pod_%d", j)
+			objects = append(objects, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: pod, Namespace: namespace},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "image"}}},
+			})
+			expectedOrder = append(expectedOrder, namespace+"/"+pod)
+		}
+	}
+
+	for _, concurrency := range []int{0, 1, 4, 50} {
+		client := Client{Clientset: testclient.NewSimpleClientset(objects...), Concurrency: concurrency}
+
+		var order []string
+		err := client.ForEachImage(&namespaces, func(image Image) error {
+			order = append(order, image.NamespaceName+"/"+image.PodName)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Got an error=%v\n", err)
+		}
+		if !reflect.DeepEqual(order, expectedOrder) {
+			t.Fatalf("Expected images in the order %v with concurrency %d but got %v\n", expectedOrder, concurrency, order)
+		}
+	}
+}
+
+func TestForEachImageWindow(t *testing.T) {
+	var objects []runtime.Object
+	var namespaces []Namespace
+	for i := 0; i < 10; i++ {
+		namespace := fmt.Sprintf("test_ns_%02d", i)
+		namespaces = append(namespaces, Namespace{Name: namespace})
+		objects = append(objects, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: namespace},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "image"}}},
+		})
+	}
+
+	clientset := testclient.NewSimpleClientset(objects...)
+	var listed atomic.Int32
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		listed.Add(1)
+		return false, nil, nil
+	})
+	client := Client{Clientset: clientset, Concurrency: 2}
+
+	calls := 0
+	err := client.ForEachImage(&namespaces, func(image Image) error {
+		if calls == 0 {
+			// Give the workers time to collect ahead of the slow consumer
+			time.Sleep(50 * time.Millisecond)
+			if n := listed.Load(); n > 2 {
+				t.Errorf("Expected at most 2 namespaces collected ahead of the consumer but got %d\n", n)
+			}
+		}
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+	if calls != 10 {
+		t.Fatalf("Expected 10 images but got %d\n", calls)
+	}
+}
+
+func TestScope(t *testing.T) {
+	objects := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shire", Labels: map[string]string{"team": "hobbits"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "rivendell", Labels: map[string]string{"team": "elves"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "mordor", Labels: map[string]string{"team": "orcs"}}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "frodo", Namespace: "shire", Labels: map[string]string{"ring": "bearer"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "hobbit"}}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "sam", Namespace: "shire"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "hobbit"}}},
+		},
+	}
+
+	testCases := []struct {
+		name               string
+		scope              Scope
+		expectedNamespaces []string
+		expectedPods       []string
+		expectError        bool
+	}{
+		{
+			name:               "NoScope",
+			expectedNamespaces: []string{"mordor", "rivendell", "shire"},
+			expectedPods:       []string{"frodo", "sam"},
+		},
+		{
+			name:               "NamespaceSelector",
+			scope:              Scope{NamespaceSelector: "team in (hobbits,elves)"},
+			expectedNamespaces: []string{"rivendell", "shire"},
+			expectedPods:       []string{"frodo", "sam"},
+		},
+		{
+			name:               "PodSelector",
+			scope:              Scope{PodSelector: "ring=bearer"},
+			expectedNamespaces: []string{"mordor", "rivendell", "shire"},
+			expectedPods:       []string{"frodo"},
+		},
+		{
+			name:               "ExcludeNamespaces",
+			scope:              Scope{ExcludeNamespaces: []string{"mordor", "shire"}},
+			expectedNamespaces: []string{"rivendell"},
+		},
+		{
+			name:               "Namespaces",
+			scope:              Scope{Namespaces: []string{"shire", "mordor", "rivendell"}, ExcludeNamespaces: []string{"rivendell"}, NamespaceSelector: "team!=orcs"},
+			expectedNamespaces: []string{"shire"},
+			expectedPods:       []string{"frodo", "sam"},
+		},
+		{
+			name:        "MissingNamespace",
+			scope:       Scope{Namespaces: []string{"isengard"}},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := Client{Clientset: testclient.NewSimpleClientset(objects...), Scope: tc.scope}
+
+			namespaces, err := client.GetNamespaces()
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("Expected an error but got none\n")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got an error=%v\n", err)
+			}
+
+			var namespaceNames []string
+			for _, namespace := range *namespaces {
+				namespaceNames = append(namespaceNames, namespace.Name)
+			}
+			sort.Strings(namespaceNames)
+			if !reflect.DeepEqual(namespaceNames, tc.expectedNamespaces) {
+				t.Fatalf("Expected namespaces %v but got %v\n", tc.expectedNamespaces, namespaceNames)
+			}
+
+			images, err := client.GetImages(namespaces)
+			if err != nil {
+				t.Fatalf("Got an error=%v\n", err)
+			}
+			var podNames []string
+			for _, image := range *images {
+				podNames = append(podNames, image.PodName)
+			}
+			if !reflect.DeepEqual(podNames, tc.expectedPods) {
+				t.Fatalf("Expected pods %v but got %v\n", tc.expectedPods, podNames)
+			}
+		})
+	}
+}
+
+func TestScopeValidate(t *testing.T) {
+	if err := (&Scope{NamespaceSelector: "team=hobbits", PodSelector: "ring in (bearer)"}).Validate(); err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+	if err := (&Scope{NamespaceSelector: "team in hobbits"}).Validate(); err == nil {
+		t.Fatalf("Expected an error for the namespace selector but got none\n")
+	}
+	if err := (&Scope{PodSelector: "ring in ("}).Validate(); err == nil {
+		t.Fatalf("Expected an error for the pod selector but got none\n")
+	}
+}
+
+func TestExcludeNamespacesSelector(t *testing.T) {
+	client := Client{Scope: Scope{ExcludeNamespaces: []string{"mordor", "isengard"}}}
+	expected := "metadata.name!=mordor,metadata.name!=isengard"
+	if selector := client.excludeNamespacesSelector(); selector != expected {
+		t.Fatalf("Expected field selector %s but got %s\n", expected, selector)
+	}
+}
+
+func TestForbidden(t *testing.T) {
+	forbidden := func(resource string) k8stesting.ReactionFunc {
+		return func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if action.GetNamespace() == "mordor" || action.GetResource().Resource == "namespaces" {
+				return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: resource}, "", errors.New("forbidden"))
+			}
+			return false, nil, nil
+		}
+	}
+
+	clientset := testclient.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "frodo", Namespace: "shire"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "hobbit"}}},
+		},
+	)
+	clientset.PrependReactor("get", "namespaces", forbidden("namespaces"))
+	clientset.PrependReactor("list", "pods", forbidden("pods"))
+
+	client := Client{Clientset: clientset, Scope: Scope{Namespaces: []string{"shire", "mordor"}}}
+	namespaces, err := client.GetNamespaces()
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+	expectedNamespaces := []Namespace{{Name: "shire"}, {Name: "mordor"}}
+	if !reflect.DeepEqual(*namespaces, expectedNamespaces) {
+		t.Fatalf("Expected namespaces %v but got %v\n", expectedNamespaces, *namespaces)
+	}
+
+	images, err := client.GetImages(namespaces)
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+	if len(*images) != 2 {
+		t.Fatalf("Expected 2 images but got %v\n", *images)
+	}
+	if image := (*images)[0]; image.PodName != "frodo" || image.Error != "" {
+		t.Fatalf("Expected the image of frodo but got %v\n", image)
+	}
+	if image := (*images)[1]; image.NamespaceName != "mordor" || !strings.Contains(image.Error, "forbidden") {
+		t.Fatalf("Expected a forbidden error for mordor but got %v\n", image)
+	}
+
+	// Without namespace metadata the namespace selector can't be applied
+	client.Scope.NamespaceSelector = "team=hobbits"
+	namespaces, err = client.GetNamespaces()
+	if err != nil || len(*namespaces) != 0 {
+		t.Fatalf("Expected no namespaces but got %v, error=%v\n", namespaces, err)
+	}
+}
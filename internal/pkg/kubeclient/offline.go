@@ -0,0 +1,227 @@
+package kubeclient
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// NewOfflineClient creates a client serving the objects of the files instead of an API server, e.g. of
+// 'kubectl get pods,namespaces -A -o json' dumps, YAML manifests or List objects. Directories are read recursively,
+// '-' reads stdin. Namespaces which are only referenced by objects are added without labels and annotations.
+// Objects given several times, e.g. by overlapping dumps, are served once with the last one read.
+func NewOfflineClient(paths []string, cfg *KubeConfig) (*Client, error) {
+	var objects []runtime.Object
+	for _, path := range paths {
+		pathObjects, err := readObjects(path)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, pathObjects...)
+	}
+
+	objects, err := removeDuplicates(objects)
+	if err != nil {
+		return nil, err
+	}
+	objects = addReferencedNamespaces(objects)
+	log.Info().Int("objects", len(objects)).Msg("Using offline input")
+
+	// The clientset of client-go serves the objects like an API server, including list options and owner lookups,
+	// so offline input takes the same code paths as a cluster. There is no server version.
+	clientset := fake.NewSimpleClientset(objects...)
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{}
+
+	return &Client{
+		Clientset:   clientset,
+		PageSize:    cfg.PageSize,
+		Concurrency: cfg.Concurrency,
+		PreDeploy:   cfg.PreDeploy,
+		Scope:       cfg.Scope,
+	}, nil
+}
+
+// readObjects reads all objects of a file, a directory of .json, .yaml and .yml files or stdin
+func readObjects(path string) ([]runtime.Object, error) {
+	if path == "-" {
+		return decodeObjects(os.Stdin, "stdin")
+	}
+
+	var objects []runtime.Object
+	err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".json", ".yaml", ".yml":
+		default:
+			// Explicitly given files are read independent of their extension
+			if file != path {
+				return nil
+			}
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		fileObjects, err := decodeObjects(f, file)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, fileObjects...)
+		return nil
+	})
+
+	return objects, err
+}
+
+// decodeObjects decodes all JSON or YAML documents, lists are flattened into their items
+func decodeObjects(r io.Reader, name string) ([]runtime.Object, error) {
+	var objects []runtime.Object
+
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bufio.NewReader(r), 4096)
+	for {
+		document := &unstructured.Unstructured{}
+		err := decoder.Decode(&document.Object)
+		if errors.Is(err, io.EOF) {
+			return objects, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("could not decode %s: %w", name, err)
+		}
+		if len(document.Object) == 0 {
+			continue
+		}
+
+		if !document.IsList() {
+			object, err := toTypedObject(document)
+			if err != nil {
+				return nil, fmt.Errorf("could not decode %s: %w", name, err)
+			}
+			if object != nil {
+				objects = append(objects, object)
+			}
+			continue
+		}
+
+		err = document.EachListItem(func(item runtime.Object) error {
+			object, err := toTypedObject(item.(*unstructured.Unstructured))
+			if object != nil {
+				objects = append(objects, object)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not decode %s: %w", name, err)
+		}
+	}
+}
+
+// toTypedObject converts the object into its built-in type, objects of other kinds are ignored
+func toTypedObject(u *unstructured.Unstructured) (runtime.Object, error) {
+	gvk := u.GroupVersionKind()
+	object, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		log.Debug().Str("kind", gvk.String()).Str("name", u.GetName()).Msg("Ignoring object of unknown kind")
+		return nil, nil
+	}
+
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, object); err != nil {
+		return nil, err
+	}
+
+	// Namespaced objects of manifests without namespace are deployed to the default namespace
+	if meta, ok := object.(metav1.Object); ok && meta.GetNamespace() == "" && isNamespaced(gvk.Kind) {
+		meta.SetNamespace(corev1.NamespaceDefault)
+	}
+
+	return object, nil
+}
+
+// isNamespaced reports whether objects of the built-in kind belong to a namespace
+func isNamespaced(kind string) bool {
+	switch kind {
+	case "Namespace", "Node", "PersistentVolume", "ClusterRole", "ClusterRoleBinding", "StorageClass",
+		"CustomResourceDefinition", "PriorityClass", "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+		return false
+	}
+	return true
+}
+
+// removeDuplicates keeps the last object of every kind, namespace and name at the position of the first one,
+// the object tracker of the clientset doesn't accept an object twice
+func removeDuplicates(objects []runtime.Object) ([]runtime.Object, error) {
+	type key struct {
+		gvk             schema.GroupVersionKind
+		namespace, name string
+	}
+
+	var unique []runtime.Object
+	indexByKey := map[key]int{}
+	for _, object := range objects {
+		gvks, _, err := scheme.Scheme.ObjectKinds(object)
+		if err != nil {
+			return nil, err
+		}
+		meta, err := apimeta.Accessor(object)
+		if err != nil {
+			return nil, err
+		}
+
+		k := key{gvk: gvks[0], namespace: meta.GetNamespace(), name: meta.GetName()}
+		if i, ok := indexByKey[k]; ok {
+			log.Debug().Str("kind", k.gvk.Kind).Str("namespace", k.namespace).Str("name", k.name).Msg("Replacing object given several times")
+			unique[i] = object
+			continue
+		}
+		indexByKey[k] = len(unique)
+		unique = append(unique, object)
+	}
+
+	return unique, nil
+}
+
+// addReferencedNamespaces adds a namespace for every namespace of an object which is not part of the objects
+func addReferencedNamespaces(objects []runtime.Object) []runtime.Object {
+	namespaces := map[string]bool{}
+	for _, object := range objects {
+		if namespace, ok := object.(*corev1.Namespace); ok {
+			namespaces[namespace.GetName()] = true
+		}
+	}
+
+	for _, object := range objects {
+		meta, ok := object.(metav1.Object)
+		if !ok || meta.GetNamespace() == "" || namespaces[meta.GetNamespace()] {
+			continue
+		}
+		namespaces[meta.GetNamespace()] = true
+		objects = append(objects, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: meta.GetNamespace()}})
+	}
+
+	return objects
+}
@@ -0,0 +1,239 @@
+package kubeclient
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const kubectlDump = `{
+    "apiVersion": "v1",
+    "kind": "List",
+    "items": [
+        {
+            "apiVersion": "v1",
+            "kind": "Namespace",
+            "metadata": {"name": "shire", "labels": {"team": "hobbits"}, "annotations": {"contact.sdase.org/slack": "#frodo"}}
+        },
+        {
+            "apiVersion": "v1",
+            "kind": "Pod",
+            "metadata": {
+                "name": "ring-7d9f-abcde",
+                "namespace": "shire",
+                "ownerReferences": [{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "ring-7d9f", "uid": "rs-uid", "controller": true}]
+            },
+            "spec": {"containers": [{"name": "ring", "image": "quay.io/sdase/ring:1.0"}]},
+            "status": {"containerStatuses": [{"name": "ring", "image": "quay.io/sdase/ring:1.0", "imageID": "quay.io/sdase/ring@sha256:1234"}]}
+        },
+        {
+            "apiVersion": "apps/v1",
+            "kind": "ReplicaSet",
+            "metadata": {
+                "name": "ring-7d9f",
+                "namespace": "shire",
+                "ownerReferences": [{"apiVersion": "apps/v1", "kind": "Deployment", "name": "ring", "uid": "deploy-uid", "controller": true}]
+            }
+        },
+        {
+            "apiVersion": "apps/v1",
+            "kind": "Deployment",
+            "metadata": {"name": "ring", "namespace": "shire", "labels": {"app": "ring"}}
+        }
+    ]
+}`
+
+const manifests = `apiVersion: v1
+kind: Pod
+metadata:
+  name: gandalf
+spec:
+  containers:
+    - name: wizard
+      image: gandalf:grey
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: gandalf
+---
+apiVersion: v1
+kind: PodList
+items:
+  - apiVersion: v1
+    kind: Pod
+    metadata:
+      name: sauron
+      namespace: mordor
+    spec:
+      containers:
+        - name: eye
+          image: sauron:latest
+`
+
+const updatedPod = `apiVersion: v1
+kind: Pod
+metadata:
+  name: ring-7d9f-abcde
+  namespace: shire
+spec:
+  containers:
+    - name: ring
+      image: quay.io/sdase/ring:1.1
+`
+
+func TestNewOfflineClient(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"dump/pods.json":             kubectlDump,
+		"manifests/pods.yaml":        manifests,
+		"manifests/README.md":        "- not a manifest",
+		"manifests/nested/.keep.yml": "",
+		"update/pod.yaml":            updatedPod,
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			t.Fatalf("Got an error=%v\n", err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+			t.Fatalf("Got an error=%v\n", err)
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		paths          []string
+		expectedImages []Image
+		expectError    bool
+	}{
+		{
+			name:  "KubectlDump",
+			paths: []string{filepath.Join(dir, "dump/pods.json")},
+			expectedImages: []Image{
+				{
+					Image:                "quay.io/sdase/ring:1.0",
+					ImageId:              "quay.io/sdase/ring@sha256:1234",
+					NamespaceName:        "shire",
+					NamespaceLabels:      map[string]string{"team": "hobbits"},
+					NamespaceAnnotations: map[string]string{"contact.sdase.org/slack": "#frodo"},
+					WorkloadLabels:       map[string]string{"app": "ring"},
+					PodName:              "ring-7d9f-abcde",
+					ContainerName:        "ring",
+					ContainerRole:        ContainerRoleApp,
+					WorkloadKind:         "Deployment",
+					WorkloadName:         "ring",
+					WorkloadUid:          "deploy-uid",
+				},
+			},
+		},
+		{
+			name:  "ManifestDirectory",
+			paths: []string{filepath.Join(dir, "manifests")},
+			expectedImages: []Image{
+				{Image: "gandalf:grey", NamespaceName: "default", PodName: "gandalf", ContainerName: "wizard", ContainerRole: ContainerRoleApp, WorkloadKind: "Pod", WorkloadName: "gandalf"},
+				{Image: "sauron:latest", NamespaceName: "mordor", PodName: "sauron", ContainerName: "eye", ContainerRole: ContainerRoleApp, WorkloadKind: "Pod", WorkloadName: "sauron"},
+			},
+		},
+		{
+			name: "OverlappingInputs",
+			paths: []string{
+				filepath.Join(dir, "dump/pods.json"), filepath.Join(dir, "dump"),
+				filepath.Join(dir, "manifests"), filepath.Join(dir, "manifests/pods.yaml"),
+			},
+			expectedImages: []Image{
+				{Image: "gandalf:grey", NamespaceName: "default", PodName: "gandalf", ContainerName: "wizard", ContainerRole: ContainerRoleApp, WorkloadKind: "Pod", WorkloadName: "gandalf"},
+				{Image: "sauron:latest", NamespaceName: "mordor", PodName: "sauron", ContainerName: "eye", ContainerRole: ContainerRoleApp, WorkloadKind: "Pod", WorkloadName: "sauron"},
+				{
+					Image:                "quay.io/sdase/ring:1.0",
+					ImageId:              "quay.io/sdase/ring@sha256:1234",
+					NamespaceName:        "shire",
+					NamespaceLabels:      map[string]string{"team": "hobbits"},
+					NamespaceAnnotations: map[string]string{"contact.sdase.org/slack": "#frodo"},
+					WorkloadLabels:       map[string]string{"app": "ring"},
+					PodName:              "ring-7d9f-abcde",
+					ContainerName:        "ring",
+					ContainerRole:        ContainerRoleApp,
+					WorkloadKind:         "Deployment",
+					WorkloadName:         "ring",
+					WorkloadUid:          "deploy-uid",
+				},
+			},
+		},
+		{
+			name:  "LastObjectWins",
+			paths: []string{filepath.Join(dir, "dump/pods.json"), filepath.Join(dir, "update/pod.yaml")},
+			expectedImages: []Image{
+				{
+					Image:                "quay.io/sdase/ring:1.1",
+					NamespaceName:        "shire",
+					NamespaceLabels:      map[string]string{"team": "hobbits"},
+					NamespaceAnnotations: map[string]string{"contact.sdase.org/slack": "#frodo"},
+					PodName:              "ring-7d9f-abcde",
+					ContainerName:        "ring",
+					ContainerRole:        ContainerRoleApp,
+					WorkloadKind:         "Pod",
+					WorkloadName:         "ring-7d9f-abcde",
+				},
+			},
+		},
+		{
+			name:        "MissingFile",
+			paths:       []string{filepath.Join(dir, "missing.json")},
+			expectError: true,
+		},
+		{
+			name:        "InvalidFile",
+			paths:       []string{filepath.Join(dir, "manifests/README.md")},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := NewOfflineClient(tc.paths, &KubeConfig{Concurrency: 2})
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("Expected an error but got none\n")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got an error=%v\n", err)
+			}
+
+			namespaces, err := client.GetNamespaces()
+			if err != nil {
+				t.Fatalf("Got an error=%v\n", err)
+			}
+			images, err := client.GetImages(namespaces)
+			if err != nil {
+				t.Fatalf("Got an error=%v\n", err)
+			}
+			if !reflect.DeepEqual(*images, tc.expectedImages) {
+				t.Fatalf("Expected images %+v but got %+v\n", tc.expectedImages, *images)
+			}
+		})
+	}
+}
+
+func TestOfflineServerVersion(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pods.json")
+	if err := os.WriteFile(file, []byte(kubectlDump), 0600); err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+
+	client, err := NewOfflineClient([]string{file}, &KubeConfig{})
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+
+	serverVersion, err := client.ServerVersion()
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+	if serverVersion != "" {
+		t.Fatalf("Expected no server version but got %s\n", serverVersion)
+	}
+}
@@ -0,0 +1,162 @@
+package kubeclient
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// States of an image, set in pre-deploy mode only, see KubeConfig.PreDeploy
+const (
+	// ImageStateRunning images are used by a pod
+	ImageStateRunning = "running"
+	// ImageStateDeclared images are declared in the pod template of a workload, but not used by any of its pods yet
+	ImageStateDeclared = "declared"
+)
+
+// template is the pod template of a workload
+type template struct {
+	workload Workload
+	template *corev1.PodTemplateSpec
+}
+
+// getTemplates returns the pod templates of all Deployments, StatefulSets, DaemonSets, Jobs and CronJobs in the namespace.
+// Jobs created by a CronJob are skipped, their template is the one of the CronJob.
+func (c *Client) getTemplates(namespace string) ([]template, error) {
+	ctx := context.Background()
+	var templates []template
+
+	add := func(kind string, object metav1.Object, podTemplate *corev1.PodTemplateSpec) {
+		templates = append(templates, template{
+			workload: Workload{
+				Kind:        kind,
+				Name:        object.GetName(),
+				Uid:         string(object.GetUID()),
+				Labels:      object.GetLabels(),
+				Annotations: object.GetAnnotations(),
+			},
+			template: podTemplate,
+		})
+	}
+
+	lists := []struct {
+		kind string
+		list func(opts metav1.ListOptions) (metav1.ListInterface, error)
+	}{
+		{"Deployment", func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+			list, err := c.Clientset.AppsV1().Deployments(namespace).List(ctx, opts)
+			if err == nil {
+				for i := range list.Items {
+					add("Deployment", &list.Items[i], &list.Items[i].Spec.Template)
+				}
+			}
+			return list, err
+		}},
+		{"StatefulSet", func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+			list, err := c.Clientset.AppsV1().StatefulSets(namespace).List(ctx, opts)
+			if err == nil {
+				for i := range list.Items {
+					add("StatefulSet", &list.Items[i], &list.Items[i].Spec.Template)
+				}
+			}
+			return list, err
+		}},
+		{"DaemonSet", func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+			list, err := c.Clientset.AppsV1().DaemonSets(namespace).List(ctx, opts)
+			if err == nil {
+				for i := range list.Items {
+					add("DaemonSet", &list.Items[i], &list.Items[i].Spec.Template)
+				}
+			}
+			return list, err
+		}},
+		{"Job", func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+			list, err := c.Clientset.BatchV1().Jobs(namespace).List(ctx, opts)
+			if err == nil {
+				for i := range list.Items {
+					if owner := metav1.GetControllerOf(&list.Items[i]); owner != nil && owner.Kind == "CronJob" {
+						continue
+					}
+					add("Job", &list.Items[i], &list.Items[i].Spec.Template)
+				}
+			}
+			return list, err
+		}},
+		{"CronJob", func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+			list, err := c.Clientset.BatchV1().CronJobs(namespace).List(ctx, opts)
+			if err == nil {
+				for i := range list.Items {
+					add("CronJob", &list.Items[i], &list.Items[i].Spec.JobTemplate.Spec.Template)
+				}
+			}
+			return list, err
+		}},
+	}
+
+	for _, l := range lists {
+		err := c.listPages(metav1.ListOptions{}, l.list)
+		if apierrors.IsForbidden(err) {
+			log.Warn().Err(err).Str("namespace", namespace).Str("kind", l.kind).Msg("Listing workloads is forbidden, skipping their templates")
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return templates, nil
+}
+
+// runningKey identifies a running image by its workload and container, see getDeclaredImages
+type runningKey struct{ kind, name, container, image string }
+
+func newRunningKey(image *Image) runningKey {
+	return runningKey{image.WorkloadKind, image.WorkloadName, image.ContainerName, image.Image}
+}
+
+// getDeclaredImages returns the images of the pod templates in the namespace which are not running
+// in the same workload and container. The pod selector is applied to the labels of the templates.
+// The labels and annotations of the template are used like the ones of a pod.
+func (c *Client) getDeclaredImages(namespace Namespace, running map[runningKey]bool) ([]Image, error) {
+	// Validated by NewClient
+	podSelector, err := labels.Parse(c.Scope.PodSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := c.getTemplates(namespace.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []Image
+	for _, t := range templates {
+		if !podSelector.Matches(labels.Set(t.template.GetLabels())) {
+			continue
+		}
+
+		pod := &corev1.Pod{ObjectMeta: t.template.ObjectMeta, Spec: t.template.Spec}
+		for _, image := range getContainerImages(pod) {
+			if running[runningKey{t.workload.Kind, t.workload.Name, image.ContainerName, image.Image}] {
+				continue
+			}
+			image.NamespaceName = namespace.Name
+			image.Labels = t.template.GetLabels()
+			image.Annotations = t.template.GetAnnotations()
+			image.NamespaceLabels = namespace.Labels
+			image.NamespaceAnnotations = namespace.Annotations
+			image.WorkloadKind = t.workload.Kind
+			image.WorkloadName = t.workload.Name
+			image.WorkloadUid = t.workload.Uid
+			image.WorkloadLabels = t.workload.Labels
+			image.WorkloadAnnotations = t.workload.Annotations
+			image.State = ImageStateDeclared
+			images = append(images, image)
+		}
+	}
+
+	return images, nil
+}
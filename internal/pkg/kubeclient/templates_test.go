@@ -0,0 +1,115 @@
+package kubeclient
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	testclient "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPreDeploy(t *testing.T) {
+	isController := true
+	podTemplate := func(labels map[string]string, images ...string) corev1.PodTemplateSpec {
+		template := corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels, Annotations: map[string]string{"contact.sdase.org/team": "fellowship"}}}
+		for i, image := range images {
+			template.Spec.Containers = append(template.Spec.Containers, corev1.Container{Name: "c" + strconv.Itoa(i), Image: image})
+		}
+		return template
+	}
+
+	objects := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "rivendell"}},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "elrond", Namespace: "rivendell", UID: "deploy-uid", Labels: map[string]string{"app": "elrond"}},
+			Spec:       appsv1.DeploymentSpec{Template: podTemplate(map[string]string{"app": "elrond"}, "elrond:1.0", "sidecar:2.0")},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "elrond-abc", Namespace: "rivendell", UID: "rs-uid",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "elrond", UID: "deploy-uid", Controller: &isController}}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "elrond-abc-1", Namespace: "rivendell", Labels: map[string]string{"app": "elrond"},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "elrond-abc", UID: "rs-uid", Controller: &isController}}},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "c0", Image: "elrond:1.0"}}},
+		},
+		&batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "rivendell", UID: "cron-uid"},
+			Spec: batchv1.CronJobSpec{JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{
+				Template: podTemplate(map[string]string{"app": "backup"}, "backup:3.0"),
+			}}},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-123", Namespace: "rivendell",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "CronJob", Name: "backup", UID: "cron-uid", Controller: &isController}}},
+			Spec: batchv1.JobSpec{Template: podTemplate(map[string]string{"app": "backup"}, "backup:3.0")},
+		},
+	}
+
+	running := Image{
+		Image: "elrond:1.0", NamespaceName: "rivendell", PodName: "elrond-abc-1", ContainerName: "c0", ContainerRole: ContainerRoleApp,
+		Labels: map[string]string{"app": "elrond"}, WorkloadLabels: map[string]string{"app": "elrond"},
+		WorkloadKind: "Deployment", WorkloadName: "elrond", WorkloadUid: "deploy-uid", State: ImageStateRunning,
+	}
+	declaredSidecar := Image{
+		Image: "sidecar:2.0", NamespaceName: "rivendell", ContainerName: "c1", ContainerRole: ContainerRoleApp,
+		Labels: map[string]string{"app": "elrond"}, Annotations: map[string]string{"contact.sdase.org/team": "fellowship"},
+		WorkloadLabels: map[string]string{"app": "elrond"}, WorkloadKind: "Deployment", WorkloadName: "elrond", WorkloadUid: "deploy-uid",
+		State: ImageStateDeclared,
+	}
+	declaredBackup := Image{
+		Image: "backup:3.0", NamespaceName: "rivendell", ContainerName: "c0", ContainerRole: ContainerRoleApp,
+		Labels: map[string]string{"app": "backup"}, Annotations: map[string]string{"contact.sdase.org/team": "fellowship"},
+		WorkloadKind: "CronJob", WorkloadName: "backup", WorkloadUid: "cron-uid", State: ImageStateDeclared,
+	}
+
+	testCases := []struct {
+		name           string
+		preDeploy      bool
+		podSelector    string
+		expectedImages []Image
+	}{
+		{
+			name: "RunningOnly",
+			expectedImages: func() []Image {
+				image := running
+				image.State = ""
+				return []Image{image}
+			}(),
+		},
+		{
+			name:           "PreDeploy",
+			preDeploy:      true,
+			expectedImages: []Image{running, declaredSidecar, declaredBackup},
+		},
+		{
+			name:           "PodSelectorOnTemplates",
+			preDeploy:      true,
+			podSelector:    "app=backup",
+			expectedImages: []Image{declaredBackup},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := Client{
+				Clientset: testclient.NewSimpleClientset(objects...),
+				PreDeploy: tc.preDeploy,
+				Scope:     Scope{PodSelector: tc.podSelector},
+			}
+
+			images, err := client.GetImages(&[]Namespace{{Name: "rivendell"}})
+			if err != nil {
+				t.Fatalf("Got an error=%v\n", err)
+			}
+			if !reflect.DeepEqual(*images, tc.expectedImages) {
+				t.Fatalf("Expected images %+v but got %+v\n", tc.expectedImages, *images)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package kubeclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// cacheSyncTimeout bounds the wait for the informer caches, which never sync if listing is not allowed
+var cacheSyncTimeout = 5 * time.Minute
+
+// Watcher keeps the namespaces and pods in the scope of the client in memory using shared informers
+type Watcher struct {
+	client *Client
+
+	// namespaces is nil if the scope has a fixed list of namespaces, their metadata is fetched once
+	namespaces      corelisters.NamespaceLister
+	fixedNamespaces []Namespace
+
+	// pods by namespace, the lister of all namespaces has the key ""
+	pods map[string]corelisters.PodLister
+	// forbidden namespaces of the fixed list with the error of listing their pods, they are not watched
+	forbidden map[string]string
+
+	mu        sync.Mutex
+	workloads map[types.UID]Workload
+}
+
+// Watch starts shared informers for the namespaces and pods in the scope of the client,
+// waits until their caches are synced and calls onChange after every add, update or delete.
+// The informers stop when the context is done. Pre-deploy mode is not supported.
+func (c *Client) Watch(ctx context.Context, resync time.Duration, onChange func()) (*Watcher, error) {
+	if c.PreDeploy {
+		return nil, errors.New("pre-deploy mode is not supported in watch mode")
+	}
+
+	w := &Watcher{
+		client:    c,
+		pods:      map[string]corelisters.PodLister{},
+		forbidden: map[string]string{},
+		workloads: map[types.UID]Workload{},
+	}
+	handler := changeHandler(onChange)
+
+	var factories []informers.SharedInformerFactory
+	var synced []cache.InformerSynced
+
+	if len(c.Scope.Namespaces) > 0 {
+		namespaces, err := c.GetNamespaces()
+		if err != nil {
+			return nil, err
+		}
+		w.fixedNamespaces = *namespaces
+
+		for _, namespace := range w.fixedNamespaces {
+			// Informers retry forbidden lists forever, so namespaces without access are reported like in ForEachImage
+			opts := metav1.ListOptions{Limit: 1}
+			c.tweakPodListOptions(&opts)
+			if _, err = c.Clientset.CoreV1().Pods(namespace.Name).List(ctx, opts); apierrors.IsForbidden(err) {
+				log.Warn().Err(err).Str("namespace", namespace.Name).Msg("Listing pods is forbidden, reporting the namespace as erroneous")
+				w.forbidden[namespace.Name] = err.Error()
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			factory := informers.NewSharedInformerFactoryWithOptions(c.Clientset, resync,
+				informers.WithNamespace(namespace.Name),
+				informers.WithTweakListOptions(c.tweakPodListOptions))
+			podInformer := factory.Core().V1().Pods()
+			if _, err = podInformer.Informer().AddEventHandler(handler); err != nil {
+				return nil, err
+			}
+			w.pods[namespace.Name] = podInformer.Lister()
+			synced = append(synced, podInformer.Informer().HasSynced)
+			factories = append(factories, factory)
+		}
+	} else {
+		namespaceFactory := informers.NewSharedInformerFactoryWithOptions(c.Clientset, resync,
+			informers.WithTweakListOptions(c.tweakNamespaceListOptions))
+		namespaceInformer := namespaceFactory.Core().V1().Namespaces()
+		if _, err := namespaceInformer.Informer().AddEventHandler(handler); err != nil {
+			return nil, err
+		}
+		w.namespaces = namespaceInformer.Lister()
+		synced = append(synced, namespaceInformer.Informer().HasSynced)
+
+		podFactory := informers.NewSharedInformerFactoryWithOptions(c.Clientset, resync,
+			informers.WithTweakListOptions(c.tweakPodListOptions))
+		podInformer := podFactory.Core().V1().Pods()
+		if _, err := podInformer.Informer().AddEventHandler(handler); err != nil {
+			return nil, err
+		}
+		w.pods[""] = podInformer.Lister()
+		synced = append(synced, podInformer.Informer().HasSynced)
+
+		factories = append(factories, namespaceFactory, podFactory)
+	}
+
+	for _, factory := range factories {
+		factory.Start(ctx.Done())
+	}
+	syncCtx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), synced...) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("informer caches did not sync within %s, check that listing and watching pods and namespaces is allowed", cacheSyncTimeout)
+	}
+
+	return w, nil
+}
+
+func (c *Client) tweakNamespaceListOptions(opts *metav1.ListOptions) {
+	opts.LabelSelector = c.Scope.NamespaceSelector
+	opts.FieldSelector = c.excludeNamespacesSelector()
+}
+
+func (c *Client) tweakPodListOptions(opts *metav1.ListOptions) {
+	opts.LabelSelector = c.Scope.PodSelector
+}
+
+// changeHandler calls onChange for every add, update or delete, except for periodic resyncs of unchanged objects
+func changeHandler(onChange func()) cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { onChange() },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldMeta, oldOk := oldObj.(metav1.Object)
+			newMeta, newOk := newObj.(metav1.Object)
+			if oldOk && newOk && oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+				return
+			}
+			onChange()
+		},
+		DeleteFunc: func(obj interface{}) { onChange() },
+	}
+}
+
+// Namespaces returns the namespaces from the informer cache, ordered by name
+func (w *Watcher) Namespaces() ([]Namespace, error) {
+	if w.namespaces == nil {
+		return w.fixedNamespaces, nil
+	}
+
+	k8Namespaces, err := w.namespaces.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var namespaces []Namespace
+	for _, k8Namespace := range k8Namespaces {
+		if slices.Contains(w.client.Scope.ExcludeNamespaces, k8Namespace.GetName()) {
+			continue
+		}
+		namespaces = append(namespaces, Namespace{
+			Name:        k8Namespace.GetName(),
+			Labels:      k8Namespace.GetLabels(),
+			Annotations: k8Namespace.GetAnnotations(),
+		})
+	}
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i].Name < namespaces[j].Name })
+
+	return namespaces, nil
+}
+
+// Images returns the images of all pods from the informer cache, ordered by namespace and pod like GetImages.
+// Workloads are cached between calls, see ResetWorkloads.
+func (w *Watcher) Images() (*[]Image, error) {
+	namespaces, err := w.Namespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var images []Image
+	for _, namespace := range namespaces {
+		if err, ok := w.forbidden[namespace.Name]; ok {
+			images = append(images, Image{
+				NamespaceName:        namespace.Name,
+				NamespaceLabels:      namespace.Labels,
+				NamespaceAnnotations: namespace.Annotations,
+				Error:                err,
+			})
+			continue
+		}
+
+		lister, ok := w.pods[namespace.Name]
+		if !ok {
+			lister = w.pods[""]
+		}
+
+		pods, err := lister.Pods(namespace.Name).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(pods, func(i, j int) bool { return pods[i].GetName() < pods[j].GetName() })
+
+		for _, pod := range pods {
+			images = append(images, w.client.getPodImages(namespace, pod, w.workloads)...)
+		}
+	}
+
+	return &images, nil
+}
+
+// ResetWorkloads clears the workload cache, e.g. on a periodic resync, so changed workload metadata is picked up
+func (w *Watcher) ResetWorkloads() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.workloads = map[types.UID]Workload{}
+}
@@ -0,0 +1,197 @@
+package kubeclient
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func forbidPods(namespace string) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if namespace == "" || action.GetNamespace() == namespace {
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("forbidden"))
+		}
+		return false, nil, nil
+	}
+}
+
+func watchedPods(t *testing.T, watcher *Watcher) []string {
+	images, err := watcher.Images()
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+	var pods []string
+	for _, image := range *images {
+		pods = append(pods, image.NamespaceName+"/"+image.PodName+"/"+image.Image)
+	}
+	return pods
+}
+
+func waitForChange(t *testing.T, changes chan struct{}) {
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected a change but got none\n")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	clientset := testclient.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shire", Labels: map[string]string{"team": "hobbits"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "mordor"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "sam", Namespace: "shire"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "hobbit"}}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "frodo", Namespace: "shire"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "hobbit"}}},
+		},
+	)
+	client := Client{Clientset: clientset, Scope: Scope{ExcludeNamespaces: []string{"mordor"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{}, 100)
+	watcher, err := client.Watch(ctx, 0, func() { changes <- struct{}{} })
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+
+	expected := []string{"shire/frodo/hobbit", "shire/sam/hobbit"}
+	if pods := watchedPods(t, watcher); !reflect.DeepEqual(pods, expected) {
+		t.Fatalf("Expected %v but got %v\n", expected, pods)
+	}
+
+	// Drain the initial adds
+	for len(changes) > 0 {
+		<-changes
+	}
+
+	_, err = clientset.CoreV1().Pods("mordor").Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "orc", Namespace: "mordor"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "orc"}}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+	_, err = clientset.CoreV1().Pods("shire").Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "bilbo", Namespace: "shire"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "old-hobbit"}}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+	waitForChange(t, changes)
+	waitForChange(t, changes)
+
+	// Pods of excluded namespaces are not part of the images
+	expected = []string{"shire/bilbo/old-hobbit", "shire/frodo/hobbit", "shire/sam/hobbit"}
+	if pods := watchedPods(t, watcher); !reflect.DeepEqual(pods, expected) {
+		t.Fatalf("Expected %v but got %v\n", expected, pods)
+	}
+
+	if err = clientset.CoreV1().Pods("shire").Delete(ctx, "sam", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+	waitForChange(t, changes)
+
+	expected = []string{"shire/bilbo/old-hobbit", "shire/frodo/hobbit"}
+	if pods := watchedPods(t, watcher); !reflect.DeepEqual(pods, expected) {
+		t.Fatalf("Expected %v but got %v\n", expected, pods)
+	}
+}
+
+func TestWatchNamespaces(t *testing.T) {
+	clientset := testclient.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shire", Labels: map[string]string{"team": "hobbits"}}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "frodo", Namespace: "shire"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "hobbit"}}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "orc", Namespace: "mordor"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "orc"}}},
+		},
+	)
+	client := Client{Clientset: clientset, Scope: Scope{Namespaces: []string{"shire"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher, err := client.Watch(ctx, 0, func() {})
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+
+	images, err := watcher.Images()
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+	if len(*images) != 1 || (*images)[0].PodName != "frodo" || (*images)[0].NamespaceLabels["team"] != "hobbits" {
+		t.Fatalf("Expected the image of frodo with namespace labels but got %v\n", *images)
+	}
+}
+
+func TestWatchForbiddenNamespace(t *testing.T) {
+	clientset := testclient.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shire"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "mordor"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "frodo", Namespace: "shire"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "hobbit"}}},
+		},
+	)
+	clientset.PrependReactor("list", "pods", forbidPods("mordor"))
+	client := Client{Clientset: clientset, Scope: Scope{Namespaces: []string{"shire", "mordor"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher, err := client.Watch(ctx, 0, func() {})
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+
+	images, err := watcher.Images()
+	if err != nil {
+		t.Fatalf("Got an error=%v\n", err)
+	}
+	if len(*images) != 2 {
+		t.Fatalf("Expected 2 images but got %v\n", *images)
+	}
+	if image := (*images)[0]; image.PodName != "frodo" || image.Error != "" {
+		t.Fatalf("Expected the image of frodo but got %v\n", image)
+	}
+	if image := (*images)[1]; image.NamespaceName != "mordor" || !strings.Contains(image.Error, "forbidden") {
+		t.Fatalf("Expected a forbidden error for mordor but got %v\n", image)
+	}
+}
+
+func TestWatchCacheSyncTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { cacheSyncTimeout = timeout }(cacheSyncTimeout)
+	cacheSyncTimeout = 100 * time.Millisecond
+
+	clientset := testclient.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shire"}})
+	clientset.PrependReactor("list", "pods", forbidPods(""))
+	client := Client{Clientset: clientset}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := client.Watch(ctx, 0, func() {}); err == nil || !strings.Contains(err.Error(), "did not sync") {
+		t.Fatalf("Expected a sync timeout but got error=%v\n", err)
+	}
+}
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,6 +14,7 @@ import (
 
 	goGit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/golang-jwt/jwt/v5"
 	"strconv"
@@ -89,10 +91,25 @@ func GetGithubToken(privateKeyFile string, githubAppId, githubInstallationId int
 
 type git struct {
 	repository *goGit.Repository
+	auth       transport.AuthMethod
 	fileName   string
+	// written is set by the first write, the clone is up to date until then
+	written bool
+}
+
+// ReadWriter is a file in the cloned repository, which is committed and pushed on every write
+type ReadWriter interface {
+	io.Writer
+	// Read returns the content of the file, nil if it does not exist
+	Read() ([]byte, error)
 }
 
 func NewGit(cfg *GitConfig, filename string) (io.Writer, error) {
+	return NewGitReadWriter(cfg, filename)
+}
+
+// NewGitReadWriter clones the repository like NewGit, the file can be read as well, e.g. to keep a state
+func NewGitReadWriter(cfg *GitConfig, filename string) (ReadWriter, error) {
 
 	if cfg.GitUrl == "" {
 		log.Info().Msg("git url not given, do not init git")
@@ -158,15 +175,34 @@ func NewGit(cfg *GitConfig, filename string) (io.Writer, error) {
 
 	g := &git{
 		repository: repository,
+		auth:       cloneOptions.Auth,
 		fileName:   filepath.Join(cfg.GitDirectory, filename),
 	}
 
 	return g, nil
 }
 
-func (g git) Write(content []byte) (int, error) {
+// Read returns the content of the file in the cloned repository, nil if it does not exist
+func (g git) Read() ([]byte, error) {
+	content, err := os.ReadFile(g.fileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return content, err
+}
+
+func (g *git) Write(content []byte) (int, error) {
 	worktree, _ := g.repository.Worktree()
 
+	// Repeated writes, e.g. in watch mode, pull the commits pushed since the clone first
+	if g.written {
+		err := worktree.Pull(&goGit.PullOptions{RemoteName: goGit.DefaultRemoteName, Auth: g.auth})
+		if err != nil && !errors.Is(err, goGit.NoErrAlreadyUpToDate) {
+			return 0, fmt.Errorf("could not pull before writing %s: %w", g.fileName, err)
+		}
+	}
+	g.written = true
+
 	err := os.WriteFile(g.fileName, content, 0755)
 	if err != nil {
 		log.Info().Stack().Err(err).Str("filename", g.fileName).Msg("Error during opening file")
@@ -2,9 +2,12 @@ package s3
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	// "github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog"
@@ -55,19 +58,48 @@ func NewS3(cfg *S3Config, fileName string) (*s3, error) {
 	return s3, nil
 }
 
-// Upload uploads the content to an S3 Bucket with a key consisting of the environmentName and the fileName.
-func (s3 s3) Write(content []byte) (int, error) {
+// Read downloads the file from the S3 Bucket, nil if it does not exist
+func (s3 s3) Read() ([]byte, error) {
+	sess, err := s3.session()
+	if err != nil {
+		return nil, err
+	}
 
-	insecureStr := strconv.FormatBool(s3.insecure)
-	log.Info().Str("s3.insecure", insecureStr).Msg("in Upload")
+	buffer := aws.NewWriteAtBuffer([]byte{})
+	_, err = s3manager.NewDownloader(sess).Download(buffer, &awss3.GetObjectInput{
+		Bucket: aws.String(s3.bucket),
+		Key:    aws.String(s3.fileName),
+	})
+
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == awss3.ErrCodeNoSuchKey {
+		log.Info().Str("fileName", s3.fileName).Msg("File does not exist in s3")
+		return nil, nil
+	} else if err != nil {
+		log.Error().Msg(fmt.Sprintf("Failed to download from S3 bucket %s, err: %v", s3.bucket, err))
+		return nil, err
+	}
 
-	sess, err := session.NewSession(&aws.Config{
+	return buffer.Bytes(), nil
+}
+
+func (s3 s3) session() (*session.Session, error) {
+	return session.NewSession(&aws.Config{
 		DisableSSL:       aws.Bool(s3.insecure),
 		S3ForcePathStyle: aws.Bool(s3.forcePathStyle),
 		Region:           aws.String(s3.region),
 		LogLevel:         getAwsLoglevel(),
 		Endpoint:         aws.String(s3.endpoint),
 	})
+}
+
+// Upload uploads the content to an S3 Bucket with a key consisting of the environmentName and the fileName.
+func (s3 s3) Write(content []byte) (int, error) {
+
+	insecureStr := strconv.FormatBool(s3.insecure)
+	log.Info().Str("s3.insecure", insecureStr).Msg("in Upload")
+
+	sess, err := s3.session()
 
 	if err != nil {
 		log.Error().Msg(fmt.Sprintf("Failed to create an aws session err: %v", err))
@@ -17,6 +17,22 @@ type StorageConfig struct {
 
 	StorageFlag string
 	FileName    string
+	// FileExtension of the default filename matching the output format, '.json' if empty
+	FileExtension string
+
+	// ServiceDescriptionFileName is the filename of the service descriptions, see NewServiceDescriptionStorage
+	ServiceDescriptionFileName string
+
+	// StateStorageFlag is the storage location of the previous run's images, see StateStorage
+	StateStorageFlag string
+	StateFileName    string
+}
+
+// StateStorage stores the images of a run to compare the next run against
+type StateStorage interface {
+	io.Writer
+	// Read returns the stored state, nil if nothing was stored yet
+	Read() ([]byte, error)
 }
 
 func NewStorage(cfg *StorageConfig, environment string) (io.Writer, error) {
@@ -27,7 +43,11 @@ func NewStorage(cfg *StorageConfig, environment string) (io.Writer, error) {
 	filename := cfg.FileName
 
 	if filename == "" {
-		filename = environment + "-output.json"
+		extension := cfg.FileExtension
+		if extension == "" {
+			extension = ".json"
+		}
+		filename = environment + "-output" + extension
 	}
 
 	switch cfg.StorageFlag {
@@ -38,9 +58,12 @@ func NewStorage(cfg *StorageConfig, environment string) (io.Writer, error) {
 	case "git":
 		w, err = git.NewGit(&cfg.GitConfig, filename)
 	case "fs":
-		var file *os.File
-		file, err = os.Create(filename)
-		w = file
+		// Created right away to fail fast, every write replaces the file
+		var f *os.File
+		if f, err = os.Create(filename); err == nil {
+			err = f.Close()
+		}
+		w = file(filename)
 	case "stdout":
 		w = os.Stdout
 	default:
@@ -50,3 +73,70 @@ func NewStorage(cfg *StorageConfig, environment string) (io.Writer, error) {
 
 	return w, err
 }
+
+// NewServiceDescriptionStorage creates a storage like NewStorage for the service descriptions of the namespaces,
+// written to a file of its own. The api storage is not supported, as it has a single endpoint for the images.
+func NewServiceDescriptionStorage(cfg *StorageConfig, environment string) (io.Writer, error) {
+	if cfg.StorageFlag == "api" {
+		return nil, fmt.Errorf("Service descriptions can't be written to the %s storage", cfg.StorageFlag)
+	}
+
+	serviceDescriptionConfig := *cfg
+	serviceDescriptionConfig.FileName = cfg.ServiceDescriptionFileName
+	if serviceDescriptionConfig.FileName == "" {
+		serviceDescriptionConfig.FileName = environment + "-service-description.json"
+	}
+	if cfg.StorageFlag == "git" {
+		// Cloned separately, as the output storage uses the same repository
+		serviceDescriptionConfig.GitDirectory = cfg.GitDirectory + "-service-description"
+	}
+
+	return NewStorage(&serviceDescriptionConfig, environment)
+}
+
+func NewStateStorage(cfg *StorageConfig, environment string) (StateStorage, error) {
+	filename := cfg.StateFileName
+
+	if filename == "" {
+		filename = environment + "-state.json"
+	}
+
+	switch cfg.StateStorageFlag {
+	case "s3":
+		s, err := s3.NewS3(&cfg.S3Config, filename)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "git":
+		if cfg.GitDirectory == "" {
+			return nil, fmt.Errorf("The git state storage requires a git directory")
+		}
+		// Cloned separately, as the output storage may use the same repository
+		gitConfig := cfg.GitConfig
+		gitConfig.GitDirectory = cfg.GitDirectory + "-state"
+		return git.NewGitReadWriter(&gitConfig, filename)
+	case "fs":
+		return file(filename), nil
+	}
+
+	return nil, fmt.Errorf("State storage flag %s is not supported", cfg.StateStorageFlag)
+}
+
+// file is a local file, replaced on every write
+type file string
+
+func (f file) Write(content []byte) (int, error) {
+	if err := os.WriteFile(string(f), content, 0644); err != nil {
+		return 0, err
+	}
+	return len(content), nil
+}
+
+func (f file) Read() ([]byte, error) {
+	content, err := os.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return content, err
+}
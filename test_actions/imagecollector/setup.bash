@@ -69,6 +69,7 @@ for pod in $(kubectl get pods -n cluster-image-scanner-image-collector | grep -v
   fi
   sleep 1
 done
+# Both files are written by the pinned sdase-image-collector of job.yml
 filesToCheck="lord-of-the-rings-output.json lord-of-the-rings-service-description.json" # missing-service-description.txt
 for fileToCheck in ${filesToCheck}; do
   ./s3download.bash "${fileToCheck}"